@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	
-	_ "github.com/lib/pq"
+	"pulsefeed-backend/internal/entities"
+
+	"github.com/lib/pq"
 )
 
 func Connect(databaseURL string) (*sql.DB, error) {
@@ -21,6 +23,13 @@ func Connect(databaseURL string) (*sql.DB, error) {
 }
 
 func Migrate(db *sql.DB) error {
+	// Posts from before hashtags were indexed are backfilled once, when the
+	// table is first created.
+	var indexHashtags bool
+	if err := db.QueryRow("SELECT to_regclass('post_hashtags') IS NULL").Scan(&indexHashtags); err != nil {
+		return fmt.Errorf("failed to check for post_hashtags: %w", err)
+	}
+
 	queries := []string{
 		`CREATE TABLE IF NOT EXISTS users (
 			id SERIAL PRIMARY KEY,
@@ -83,6 +92,211 @@ func Migrate(db *sql.DB) error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		
+		// Google sign-in: accounts may be linked to a Google subject and may have no password
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS google_id VARCHAR(255) UNIQUE`,
+		`ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL`,
+		
+		// Phone OTP sign-in: phone-only accounts have no email
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number VARCHAR(20) UNIQUE`,
+		`ALTER TABLE users ALTER COLUMN email DROP NOT NULL`,
+		
+		// TOTP two-factor authentication
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(64)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN DEFAULT FALSE`,
+		
+		`CREATE TABLE IF NOT EXISTS recovery_codes (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			code_hash VARCHAR(64) NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, code_hash)
+		)`,
+		
+		// Roles and moderation
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'`,
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check`,
+		`ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'moderator', 'admin'))`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason TEXT DEFAULT ''`,
+		
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id SERIAL PRIMARY KEY,
+			actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			action VARCHAR(50) NOT NULL,
+			target_type VARCHAR(20) NOT NULL,
+			target_id INTEGER NOT NULL,
+			details JSONB DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
+		// Post editing
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP`,
+		
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			id SERIAL PRIMARY KEY,
+			post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+			content TEXT NOT NULL,
+			media_urls JSONB DEFAULT '[]',
+			media_type VARCHAR(20) DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
+		// Post deletion: posts are soft-deleted and purged later, and
+		// notifications keep their post_id so they can say the post was removed
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_post_id_fkey`,
+		
+		// Hashtags, stored lowercased without the '#'
+		`CREATE TABLE IF NOT EXISTS hashtags (
+			id SERIAL PRIMARY KEY,
+			tag VARCHAR(100) UNIQUE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
+		`CREATE TABLE IF NOT EXISTS post_hashtags (
+			post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+			hashtag_id INTEGER REFERENCES hashtags(id) ON DELETE CASCADE,
+			PRIMARY KEY(post_id, hashtag_id)
+		)`,
+		
+		// Mentions, resolved to users when a post or comment is written
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS mentions JSONB DEFAULT '[]'`,
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS mentions JSONB DEFAULT '[]'`,
+		
+		`CREATE TABLE IF NOT EXISTS blocks (
+			id SERIAL PRIMARY KEY,
+			blocker_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			blocked_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(blocker_id, blocked_id),
+			CHECK(blocker_id != blocked_id)
+		)`,
+		
+		// Full-text post search
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('english', content)) STORED`,
+		
+		// Reposts are posts with no content of their own that go when the
+		// original is purged. Quotes keep quote_of_id without a foreign key so
+		// they can show a tombstone once the original is gone.
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS repost_of_id INTEGER REFERENCES posts(id) ON DELETE CASCADE`,
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS quote_of_id INTEGER`,
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS reposts_count INTEGER DEFAULT 0`,
+		
+		// Denormalized user counts, kept up to date by triggers below and
+		// backfilled once when the columns are added
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			               WHERE table_name = 'users' AND column_name = 'followers_count') THEN
+				ALTER TABLE users ADD COLUMN followers_count INTEGER NOT NULL DEFAULT 0;
+				ALTER TABLE users ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+				ALTER TABLE users ADD COLUMN posts_count INTEGER NOT NULL DEFAULT 0;
+				UPDATE users u SET
+					followers_count = (SELECT COUNT(*) FROM follows WHERE following_id = u.id),
+					following_count = (SELECT COUNT(*) FROM follows WHERE follower_id = u.id),
+					posts_count = (SELECT COUNT(*) FROM posts
+					               WHERE user_id = u.id AND deleted_at IS NULL AND repost_of_id IS NULL);
+			END IF;
+		END
+		$$`,
+		
+		// Fuzzy user search
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		
+		// Comment replies
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE`,
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS replies_count INTEGER DEFAULT 0`,
+		
+		// Comment edits and likes
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP`,
+		`ALTER TABLE comments ADD COLUMN IF NOT EXISTS likes_count INTEGER DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS comment_likes (
+			id SERIAL PRIMARY KEY,
+			comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(comment_id, user_id)
+		)`,
+		
+		// Reactions. A like is a reaction of kind 'like'; likes_count stays
+		// the total across kinds and reaction_counts breaks it down, backfilled
+		// once when the column is added.
+		`ALTER TABLE likes ADD COLUMN IF NOT EXISTS kind VARCHAR(32) NOT NULL DEFAULT 'like'`,
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+			               WHERE table_name = 'posts' AND column_name = 'reaction_counts') THEN
+				ALTER TABLE posts ADD COLUMN reaction_counts JSONB NOT NULL DEFAULT '{}';
+				UPDATE posts p SET reaction_counts = k.counts
+				FROM (SELECT post_id, jsonb_object_agg(kind, n) AS counts
+				      FROM (SELECT post_id, kind, COUNT(*) AS n FROM likes GROUP BY post_id, kind) c
+				      GROUP BY post_id) k
+				WHERE p.id = k.post_id;
+			END IF;
+		END
+		$$`,
+		
+		// Bookmarks, private to the user who saved them. Each is in at most
+		// one collection and falls out of it if the collection is deleted.
+		`CREATE TABLE IF NOT EXISTS bookmark_collections (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS bookmarks (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+			collection_id INTEGER REFERENCES bookmark_collections(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, post_id)
+		)`,
+		
+		// Who uploaded each file, so posts can only attach their author's
+		// uploads and the purge knows what it may delete
+		`CREATE TABLE IF NOT EXISTS uploads (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			url VARCHAR(255) UNIQUE NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id UUID PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			device_name VARCHAR(100) DEFAULT '',
+			user_agent TEXT DEFAULT '',
+			ip_address VARCHAR(45) DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id SERIAL PRIMARY KEY,
+			token_id UUID UNIQUE NOT NULL,
+			family_id UUID NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			expires_at TIMESTAMP NOT NULL,
+			rotated_at TIMESTAMP,
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
+		`CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			token_hash VARCHAR(64) UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		
 		// Indexes for performance
 		`CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC)`,
@@ -93,17 +307,58 @@ func Migrate(db *sql.DB) error {
 		`CREATE INDEX IF NOT EXISTS idx_follows_following_id ON follows(following_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_notifications_created_at ON notifications(created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_keyset ON posts(created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_user_keyset ON posts(user_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_keyset ON notifications(user_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_revisions_post_id ON post_revisions(post_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(deleted_at) WHERE deleted_at IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_post_hashtags_hashtag_id ON post_hashtags(hashtag_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_blocks_blocked_id ON blocks(blocked_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN(search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_username_trgm ON users USING GIN(username gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users USING GIN(full_name gin_trgm_ops)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_one_repost ON posts(user_id, repost_of_id)
+			WHERE repost_of_id IS NOT NULL AND deleted_at IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_quote_of_id ON posts(quote_of_id) WHERE quote_of_id IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_thread ON comments(post_id, created_at, id) WHERE parent_id IS NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id, created_at, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_likes_post_keyset ON likes(post_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_bookmarks_user_keyset ON bookmarks(user_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id ON bookmarks(collection_id, created_at DESC, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_comment_likes_user_id ON comment_likes(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id)`,
 		
 		// Triggers for updating counts
+		`CREATE OR REPLACE FUNCTION adjust_reaction_count(counts JSONB, kind TEXT, delta INTEGER)
+		RETURNS JSONB AS $$
+			SELECT CASE WHEN COALESCE((counts->>kind)::int, 0) + delta > 0
+			            THEN jsonb_set(counts, ARRAY[kind], to_jsonb(COALESCE((counts->>kind)::int, 0) + delta))
+			            ELSE counts - kind END
+		$$ LANGUAGE sql IMMUTABLE`,
+		
 		`CREATE OR REPLACE FUNCTION update_likes_count()
 		RETURNS TRIGGER AS $$
 		BEGIN
 			IF TG_OP = 'INSERT' THEN
-				UPDATE posts SET likes_count = likes_count + 1 WHERE id = NEW.post_id;
+				UPDATE posts SET likes_count = likes_count + 1,
+				       reaction_counts = adjust_reaction_count(reaction_counts, NEW.kind, 1)
+				WHERE id = NEW.post_id;
 				RETURN NEW;
 			ELSIF TG_OP = 'DELETE' THEN
-				UPDATE posts SET likes_count = likes_count - 1 WHERE id = OLD.post_id;
+				UPDATE posts SET likes_count = likes_count - 1,
+				       reaction_counts = adjust_reaction_count(reaction_counts, OLD.kind, -1)
+				WHERE id = OLD.post_id;
 				RETURN OLD;
+			ELSIF TG_OP = 'UPDATE' AND NEW.kind <> OLD.kind THEN
+				UPDATE posts SET reaction_counts = adjust_reaction_count(
+				           adjust_reaction_count(reaction_counts, OLD.kind, -1), NEW.kind, 1)
+				WHERE id = NEW.post_id;
+				RETURN NEW;
 			END IF;
 			RETURN NULL;
 		END;
@@ -123,15 +378,117 @@ func Migrate(db *sql.DB) error {
 		END;
 		$$ LANGUAGE plpgsql`,
 		
+		`CREATE OR REPLACE FUNCTION update_replies_count()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' AND NEW.parent_id IS NOT NULL THEN
+				UPDATE comments SET replies_count = replies_count + 1 WHERE id = NEW.parent_id;
+			ELSIF TG_OP = 'DELETE' AND OLD.parent_id IS NOT NULL THEN
+				UPDATE comments SET replies_count = replies_count - 1 WHERE id = OLD.parent_id;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		
+		`CREATE OR REPLACE FUNCTION update_comment_likes_count()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				UPDATE comments SET likes_count = likes_count + 1 WHERE id = NEW.comment_id;
+			ELSIF TG_OP = 'DELETE' THEN
+				UPDATE comments SET likes_count = likes_count - 1 WHERE id = OLD.comment_id;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		
+		`CREATE OR REPLACE FUNCTION update_follow_counts()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' THEN
+				UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.following_id;
+				UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+				RETURN NEW;
+			ELSIF TG_OP = 'DELETE' THEN
+				UPDATE users SET followers_count = followers_count - 1 WHERE id = OLD.following_id;
+				UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
+				RETURN OLD;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		
+		// Reposts don't count. Soft-deleted posts stop counting when they're
+		// deleted, not when they're purged.
+		`CREATE OR REPLACE FUNCTION update_posts_count()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP = 'INSERT' AND NEW.deleted_at IS NULL AND NEW.repost_of_id IS NULL THEN
+				UPDATE users SET posts_count = posts_count + 1 WHERE id = NEW.user_id;
+			ELSIF TG_OP = 'DELETE' AND OLD.deleted_at IS NULL AND OLD.repost_of_id IS NULL THEN
+				UPDATE users SET posts_count = posts_count - 1 WHERE id = OLD.user_id;
+			ELSIF TG_OP = 'UPDATE' AND NEW.repost_of_id IS NULL
+			   AND OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+				UPDATE users SET posts_count = posts_count - 1 WHERE id = NEW.user_id;
+			ELSIF TG_OP = 'UPDATE' AND NEW.repost_of_id IS NULL
+			   AND OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+				UPDATE users SET posts_count = posts_count + 1 WHERE id = NEW.user_id;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		
+		// reposts_count counts live reposts and quotes of a post.
+		`CREATE OR REPLACE FUNCTION update_reposts_count()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL
+			   AND (TG_OP = 'INSERT' OR OLD.deleted_at IS NOT NULL) THEN
+				UPDATE posts SET reposts_count = reposts_count + 1
+				WHERE id = COALESCE(NEW.repost_of_id, NEW.quote_of_id);
+			ELSIF TG_OP IN ('DELETE', 'UPDATE') AND OLD.deleted_at IS NULL
+			   AND (TG_OP = 'DELETE' OR NEW.deleted_at IS NOT NULL) THEN
+				UPDATE posts SET reposts_count = reposts_count - 1
+				WHERE id = COALESCE(OLD.repost_of_id, OLD.quote_of_id);
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		
 		`DROP TRIGGER IF EXISTS trigger_likes_count ON likes`,
 		`CREATE TRIGGER trigger_likes_count
-		AFTER INSERT OR DELETE ON likes
+		AFTER INSERT OR DELETE OR UPDATE OF kind ON likes
 		FOR EACH ROW EXECUTE FUNCTION update_likes_count()`,
 		
 		`DROP TRIGGER IF EXISTS trigger_comments_count ON comments`,
 		`CREATE TRIGGER trigger_comments_count
 		AFTER INSERT OR DELETE ON comments
 		FOR EACH ROW EXECUTE FUNCTION update_comments_count()`,
+		
+		`DROP TRIGGER IF EXISTS trigger_replies_count ON comments`,
+		`CREATE TRIGGER trigger_replies_count
+		AFTER INSERT OR DELETE ON comments
+		FOR EACH ROW EXECUTE FUNCTION update_replies_count()`,
+		
+		`DROP TRIGGER IF EXISTS trigger_comment_likes_count ON comment_likes`,
+		`CREATE TRIGGER trigger_comment_likes_count
+		AFTER INSERT OR DELETE ON comment_likes
+		FOR EACH ROW EXECUTE FUNCTION update_comment_likes_count()`,
+		
+		`DROP TRIGGER IF EXISTS trigger_follow_counts ON follows`,
+		`CREATE TRIGGER trigger_follow_counts
+		AFTER INSERT OR DELETE ON follows
+		FOR EACH ROW EXECUTE FUNCTION update_follow_counts()`,
+		
+		`DROP TRIGGER IF EXISTS trigger_posts_count ON posts`,
+		`CREATE TRIGGER trigger_posts_count
+		AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+		FOR EACH ROW EXECUTE FUNCTION update_posts_count()`,
+		
+		`DROP TRIGGER IF EXISTS trigger_reposts_count ON posts`,
+		`CREATE TRIGGER trigger_reposts_count
+		AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON posts
+		FOR EACH ROW EXECUTE FUNCTION update_reposts_count()`,
 	}
 
 	for _, query := range queries {
@@ -140,5 +497,62 @@ func Migrate(db *sql.DB) error {
 		}
 	}
 
+	if indexHashtags {
+		if err := backfillHashtags(db); err != nil {
+			return fmt.Errorf("failed to backfill hashtags: %w", err)
+		}
+	}
+
 	return nil
 }
+
+// backfillHashtags indexes the hashtags in every existing post, using the
+// same rules as new posts.
+func backfillHashtags(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, content FROM posts")
+	if err != nil {
+		return err
+	}
+	postTags := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if tags := entities.Hashtags(content); len(tags) > 0 {
+			postTags[id] = tags
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, tags := range postTags {
+		if _, err := tx.Exec(`
+			INSERT INTO hashtags (tag)
+			SELECT unnest($1::text[])
+			ON CONFLICT (tag) DO NOTHING`,
+			pq.Array(tags)); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO post_hashtags (post_id, hashtag_id)
+			SELECT $1, id FROM hashtags WHERE tag = ANY($2)
+			ON CONFLICT DO NOTHING`,
+			id, pq.Array(tags)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,88 @@
+// Package entities finds hashtags and mentions in user-written text.
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxHashtagLength is the longest tag, in characters, that gets indexed.
+const MaxHashtagLength = 100
+
+// MaxUsernameLength matches the users.username column.
+const MaxUsernameLength = 50
+
+// A hashtag starts at the beginning of the text or after a character that
+// can't be part of a word, so "a#b" and "&#39;" aren't tags.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]+)`)
+
+// Usernames are ASCII letters, digits and underscores. A mention can't
+// follow a word character, so email addresses aren't mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@])@([A-Za-z0-9_]+)`)
+
+// Mention is an @username in text. Start and End are character (not byte)
+// offsets, Start at the '@' and End just past the username.
+type Mention struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// Hashtags returns the distinct tags in text, lowercased and without the
+// leading '#', in the order they first appear.
+func Hashtags(text string) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag, ok := NormalizeHashtag(match[1])
+		if !ok || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag lowercases tag and strips a leading '#', reporting false
+// if what's left isn't a tag Hashtags would return.
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return "", false
+	}
+
+	// Tags need at least one letter, so "#1" is just a number.
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsNumber(r) || r == '_':
+		default:
+			return "", false
+		}
+	}
+	return tag, hasLetter
+}
+
+// Mentions returns every @username in text in order, including repeats.
+func Mentions(text string) []Mention {
+	mentions := []Mention{}
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		username := text[match[2]:match[3]]
+		if len(username) > MaxUsernameLength {
+			continue
+		}
+
+		start := utf8.RuneCountInString(text[:match[2]-1])
+		mentions = append(mentions, Mention{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + len(username),
+		})
+	}
+	return mentions
+}
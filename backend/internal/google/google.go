@@ -0,0 +1,278 @@
+package google
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CertsURL is Google's published JWKS endpoint for ID token signing keys.
+const CertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+var (
+	ErrNotConfigured = errors.New("google sign-in is not configured")
+	ErrKeyNotFound   = errors.New("signing key not found")
+	ErrInvalidToken  = errors.New("invalid google id token")
+)
+
+var validIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+// KeySource resolves the RSA public key that signed an ID token by its kid.
+type KeySource interface {
+	Key(kid string) (*rsa.PublicKey, error)
+}
+
+// StaticKeySource serves a fixed key set, e.g. one generated locally in tests.
+type StaticKeySource map[string]*rsa.PublicKey
+
+func (s StaticKeySource) Key(kid string) (*rsa.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// minRefreshInterval is the least time between two JWKS fetches, so tokens
+// with made-up kids can't make every sign-in call out to Google.
+const minRefreshInterval = time.Minute
+
+// JWKSKeySource fetches keys from a JWKS URL and caches them for as long as
+// the response's Cache-Control max-age allows.
+type JWKSKeySource struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	expires time.Time
+	// fetched is when the last fetch finished and err what it failed with.
+	fetched time.Time
+	err     error
+	// fetching is closed when the fetch in progress, if any, finishes.
+	fetching chan struct{}
+}
+
+func NewJWKSKeySource(url string) *JWKSKeySource {
+	return &JWKSKeySource{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *JWKSKeySource) Key(kid string) (*rsa.PublicKey, error) {
+	for {
+		s.mu.Lock()
+		key, ok := s.keys[kid]
+		if ok && time.Now().Before(s.expires) {
+			s.mu.Unlock()
+			return key, nil
+		}
+
+		// Unknown kid or stale cache: Google may have rotated keys, so
+		// refetch, but not again until minRefreshInterval has passed. Until
+		// then a stale key is still better than none.
+		if time.Since(s.fetched) < minRefreshInterval {
+			err := s.err
+			s.mu.Unlock()
+			if ok {
+				return key, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrKeyNotFound
+		}
+
+		// Only one caller fetches; the rest wait for it and look again.
+		if s.fetching != nil {
+			fetching := s.fetching
+			s.mu.Unlock()
+			<-fetching
+			continue
+		}
+		fetching := make(chan struct{})
+		s.fetching = fetching
+		s.mu.Unlock()
+
+		// The fetch runs without the lock so cached keys stay available.
+		keys, expires, err := s.fetch()
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys, s.expires = keys, expires
+		}
+		s.fetched, s.err = time.Now(), err
+		s.fetching = nil
+		close(fetching)
+		s.mu.Unlock()
+	}
+}
+
+func (s *JWKSKeySource) fetch() (map[string]*rsa.PublicKey, time.Time, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys, err := set.rsaKeys()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return keys, time.Now().Add(maxAge(resp.Header.Get("Cache-Control"))), nil
+}
+
+// ParseJWKS decodes a JWKS document into a StaticKeySource.
+func ParseJWKS(data []byte) (StaticKeySource, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys, err := set.rsaKeys()
+	if err != nil {
+		return nil, err
+	}
+	return StaticKeySource(keys), nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (set jwks) rsaKeys() (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus for key %s: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent for key %s: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return time.Hour
+}
+
+// Claims holds the identity fields we use from a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+type Verifier struct {
+	clientID string
+	keys     KeySource
+}
+
+func NewVerifier(clientID string, keys KeySource) *Verifier {
+	return &Verifier{
+		clientID: clientID,
+		keys:     keys,
+	}
+}
+
+// Verify checks the ID token's signature, audience, issuer and expiry.
+func (v *Verifier) Verify(idToken string) (*Claims, error) {
+	if v == nil || v.clientID == "" {
+		return nil, ErrNotConfigured
+	}
+
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(kid)
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience(v.clientID))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if !contains(validIssuers, issuer) {
+		return nil, ErrInvalidToken
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	result := &Claims{Subject: subject}
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	result.Picture, _ = claims["picture"].(string)
+
+	// Google has historically sent email_verified as either a bool or a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = verified
+	case string:
+		result.EmailVerified = verified == "true"
+	}
+
+	return result, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
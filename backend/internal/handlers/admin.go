@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit log actions.
+const (
+	auditUserSuspend   = "user.suspend"
+	auditUserUnsuspend = "user.unsuspend"
+	auditUserVerify    = "user.verify"
+	auditUserRole      = "user.role"
+	auditPostTakedown  = "post.takedown"
+	auditPostDelete    = "post.delete"
+)
+
+var roleRank = map[string]int{
+	models.RoleUser:      0,
+	models.RoleModerator: 1,
+	models.RoleAdmin:     2,
+}
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	searchTerm := ""
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		searchTerm = "%" + strings.ToLower(q) + "%"
+	}
+
+	rows, err := h.db.Query(`
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.created_at, u.updated_at, u.role, u.suspended_at, u.suspended_until,
+		       COALESCE(u.suspension_reason, '')
+		FROM users u
+		WHERE ($1 = '' OR LOWER(u.username) LIKE $1 OR LOWER(COALESCE(u.email, '')) LIKE $1
+		       OR LOWER(u.full_name) LIKE $1)
+		  AND ($2 = '' OR u.role = $2)
+		  AND (NOT $3 OR NOT `+notSuspendedSQL+`)
+		ORDER BY u.created_at DESC
+		LIMIT $4 OFFSET $5`,
+		searchTerm, c.Query("role"), c.Query("suspended") == "true", limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+			&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+			&user.Role, &user.SuspendedAt, &user.SuspendedUntil, &user.SuspensionReason)
+		if err != nil {
+			continue
+		}
+
+		users = append(users, &user)
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handler) SuspendUser(c *gin.Context) {
+	actorID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkModerationTarget(c, targetID) {
+		return
+	}
+
+	var until *time.Time
+	if req.DurationHours > 0 {
+		t := time.Now().Add(time.Duration(req.DurationHours) * time.Hour)
+		until = &t
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET suspended_at = CURRENT_TIMESTAMP, suspended_until = $2, suspension_reason = $3,
+		       updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		targetID, until, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	if err := writeAudit(tx, actorID, auditUserSuspend, "user", targetID, gin.H{
+		"reason":          req.Reason,
+		"duration_hours":  req.DurationHours,
+		"suspended_until": until,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.redis.Delete(redis.UserCacheKey(targetID))
+	h.enforceSuspension(targetID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended", "suspended_until": until})
+}
+
+func (h *Handler) UnsuspendUser(c *gin.Context) {
+	actorID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if !h.checkModerationTarget(c, targetID) {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET suspended_at = NULL, suspended_until = NULL, suspension_reason = '',
+		       updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND suspended_at IS NOT NULL`,
+		targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift suspension"})
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is not suspended"})
+		return
+	}
+
+	if err := writeAudit(tx, actorID, auditUserUnsuspend, "user", targetID, gin.H{}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift suspension"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.redis.Delete(redis.UserCacheKey(targetID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suspension lifted"})
+}
+
+// VerifyUser marks an account verified without going through the email link.
+func (h *Handler) VerifyUser(c *gin.Context) {
+	actorID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var wasVerified bool
+	err = tx.QueryRow("SELECT is_verified FROM users WHERE id = $1 FOR UPDATE", targetID).Scan(&wasVerified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if wasVerified {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already verified"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE users SET is_verified = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify user"})
+		return
+	}
+
+	if err := writeAudit(tx, actorID, auditUserVerify, "user", targetID, gin.H{}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify user"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.redis.Delete(redis.UserCacheKey(targetID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "User verified"})
+}
+
+// UpdateUserRole promotes or demotes an account. Roles travel in the access
+// token, so a demotion also signs the user out everywhere rather than leaving
+// the old role usable until the token expires.
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	actorID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if targetID == actorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var previousRole string
+	err = tx.QueryRow("SELECT role FROM users WHERE id = $1 FOR UPDATE", targetID).Scan(&previousRole)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if previousRole == req.Role {
+		c.JSON(http.StatusOK, gin.H{"message": "Role unchanged", "role": req.Role})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		req.Role, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	var revoked []string
+	if roleRank[req.Role] < roleRank[previousRole] {
+		revoked, err = revokeSessions(tx, targetID, "", "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+			return
+		}
+	}
+
+	if err := writeAudit(tx, actorID, auditUserRole, "user", targetID, gin.H{
+		"from": previousRole,
+		"to":   req.Role,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.afterSessionsRevoked(revoked)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "role": req.Role})
+}
+
+func (h *Handler) TakeDownPost(c *gin.Context) {
+	actorID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req models.TakedownPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// The audit entry keeps a copy of what was removed, since the post
+	// itself is purged once the retention period is up.
+	var ownerID int
+	var content string
+	var mediaURLs models.MediaURLs
+	err = tx.QueryRow(`
+		UPDATE posts SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING user_id, content, media_urls`,
+		postID).Scan(&ownerID, &content, &mediaURLs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to take down post"})
+		return
+	}
+
+	if err := writeAudit(tx, actorID, auditPostTakedown, "post", postID, gin.H{
+		"reason":     req.Reason,
+		"owner_id":   ownerID,
+		"content":    content,
+		"media_urls": mediaURLs,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to take down post"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.postRemoved(postID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post taken down"})
+}
+
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 200 {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	targetID := 0
+	if t := c.Query("target_id"); t != "" {
+		parsed, err := strconv.Atoi(t)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+			return
+		}
+		targetID = parsed
+	}
+
+	actorID := 0
+	if a := c.Query("actor_id"); a != "" {
+		parsed, err := strconv.Atoi(a)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return
+		}
+		actorID = parsed
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, actor_id, action, target_type, target_id, details, created_at
+		FROM audit_log
+		WHERE ($1 = '' OR target_type = $1) AND ($2 = 0 OR target_id = $2)
+		  AND ($3 = 0 OR actor_id = $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4 OFFSET $5`,
+		c.Query("target_type"), targetID, actorID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+		return
+	}
+	defer rows.Close()
+
+	entries := []*models.AuditLogEntry{}
+	for rows.Next() {
+		var entry models.AuditLogEntry
+		var entryActorID sql.NullInt64
+		var details []byte
+		err := rows.Scan(&entry.ID, &entryActorID, &entry.Action, &entry.TargetType, &entry.TargetID,
+			&details, &entry.CreatedAt)
+		if err != nil {
+			continue
+		}
+
+		if entryActorID.Valid {
+			id := int(entryActorID.Int64)
+			entry.ActorID = &id
+		}
+		entry.Details = json.RawMessage(details)
+		entries = append(entries, &entry)
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// checkModerationTarget makes sure the acting user may moderate targetID and
+// writes the error response if not. Nobody moderates their own account, and
+// only admins can act on other staff.
+func (h *Handler) checkModerationTarget(c *gin.Context, targetID int) bool {
+	if targetID == c.GetInt("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot moderate your own account"})
+		return false
+	}
+
+	var role string
+	err := h.db.QueryRow("SELECT role FROM users WHERE id = $1", targetID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	if role != models.RoleUser && c.GetString("role") != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can moderate staff accounts"})
+		return false
+	}
+
+	return true
+}
+
+// writeAudit records a moderation action. Callers pass their transaction so
+// the entry is only kept if the action itself commits.
+func writeAudit(db execer, actorID int, action, targetType string, targetID int, details gin.H) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, details)
+		VALUES ($1, $2, $3, $4, $5)`,
+		actorID, action, targetType, targetID, string(payload))
+	return err
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlockUser blocks another account and removes any follows between the two.
+func (h *Handler) BlockUser(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	targetUserID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if userID == targetUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot block yourself"})
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", targetUserID).Scan(&exists)
+	if err != nil || !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`,
+		userID, targetUserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM follows
+		WHERE (follower_id = $1 AND following_id = $2) OR (follower_id = $2 AND following_id = $1)`,
+		userID, targetUserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Clear cache
+	for _, id := range []int{userID, targetUserID} {
+		h.redis.Delete(redis.UserCacheKey(id))
+		h.redis.DeleteTimeline(redis.TimelineKey(id))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+func (h *Handler) UnblockUser(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	targetUserID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2", userID, targetUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// blockedSQL is a condition that is true when user u.id and the user ID in
+// the placeholder param (e.g. "$2") have blocked each other either way.
+func blockedSQL(param string) string {
+	return `EXISTS(SELECT 1 FROM blocks
+		WHERE (blocker_id = u.id AND blocked_id = ` + param + `) OR (blocker_id = ` + param + ` AND blocked_id = u.id))`
+}
+
+// rejectBlocked writes a 403 and returns true if userID and otherID have
+// blocked each other either way, so neither can interact with the other's
+// posts and comments.
+func (h *Handler) rejectBlocked(c *gin.Context, userID, otherID int) bool {
+	blocked, err := h.isBlocked(userID, otherID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return true
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't interact with this user"})
+		return true
+	}
+	return false
+}
+
+// isBlocked reports whether either user has blocked the other.
+func (h *Handler) isBlocked(userID, otherID int) (bool, error) {
+	var blocked bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))`,
+		userID, otherID).Scan(&blocked)
+	return blocked, err
+}
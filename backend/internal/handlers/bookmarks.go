@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"strconv"
+
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookmarkPost saves a post for the user. Saving a post that is already
+// bookmarked moves it to the collection given, or out of its collection if
+// none is.
+func (h *Handler) BookmarkPost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.BookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var postOwnerID int
+	err = h.db.QueryRow("SELECT user_id FROM posts WHERE id = $1 AND deleted_at IS NULL", postID).Scan(&postOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if h.rejectBlocked(c, userID, postOwnerID) {
+		return
+	}
+
+	if req.CollectionID != nil && !h.ownsCollection(c, userID, *req.CollectionID) {
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO bookmarks (user_id, post_id, collection_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET collection_id = EXCLUDED.collection_id`,
+		userID, postID, req.CollectionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post bookmarked"})
+}
+
+func (h *Handler) UnbookmarkPost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM bookmarks WHERE user_id = $1 AND post_id = $2", userID, postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// GetBookmarks lists the user's bookmarked posts, most recently saved first,
+// optionally only those in ?collection_id=. Posts that have since been
+// deleted, or whose authors are suspended or blocked either way, are left
+// out.
+func (h *Handler) GetBookmarks(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var collectionID *int
+	if id := c.Query("collection_id"); id != "" {
+		parsed, err := strconv.Atoi(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+			return
+		}
+		if !h.ownsCollection(c, userID, parsed) {
+			return
+		}
+		collectionID = &parsed
+	}
+
+	scope := bookmarksScope(userID, collectionID)
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
+	}
+	afterCreatedAt, afterID := pg.afterArgs()
+
+	rows, err := h.db.Query(`
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$1")+`,
+		       b.created_at, b.id
+		FROM bookmarks b
+		JOIN posts p ON b.post_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE b.user_id = $1 AND ($2::int IS NULL OR b.collection_id = $2)
+		  AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$1")+`
+		  AND ($3::timestamp IS NULL OR (b.created_at, b.id) < ($3::timestamp, $4::int))
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT $5 OFFSET $6`,
+		userID, collectionID, afterCreatedAt, afterID, pg.limit+1, pg.offset)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookmarks"})
+		return
+	}
+	defer rows.Close()
+
+	posts := []*models.Post{}
+	saved := make(map[int]cursor.Position)
+	for rows.Next() {
+		var savedAt cursor.Position
+		post, err := scanPost(withExtra(rows, &savedAt.CreatedAt, &savedAt.ID))
+		if err != nil {
+			continue
+		}
+		saved[post.ID] = savedAt
+		posts = append(posts, post)
+	}
+
+	hasMore := len(posts) > pg.limit
+	if hasMore {
+		posts = posts[:pg.limit]
+	}
+
+	response := models.FeedResponse{HasMore: hasMore}
+	if len(posts) > 0 {
+		last := saved[posts[len(posts)-1].ID]
+		response.NextCursor = h.nextCursor(scope, hasMore, last.CreatedAt, last.ID)
+	}
+
+	posts, err = h.attachEmbeds(userID, posts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookmarks"})
+		return
+	}
+	response.Posts = posts
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetBookmarkCollections(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	rows, err := h.db.Query(`
+		SELECT bc.id, bc.user_id, bc.name, bc.created_at,
+		       (SELECT COUNT(*) FROM bookmarks WHERE collection_id = bc.id) AS bookmarks_count
+		FROM bookmark_collections bc
+		WHERE bc.user_id = $1
+		ORDER BY bc.name`,
+		userID)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get collections"})
+		return
+	}
+	defer rows.Close()
+
+	collections := []*models.BookmarkCollection{}
+	for rows.Next() {
+		var collection models.BookmarkCollection
+		err := rows.Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt,
+			&collection.BookmarksCount)
+		if err != nil {
+			continue
+		}
+		collections = append(collections, &collection)
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+func (h *Handler) CreateBookmarkCollection(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.CreateBookmarkCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := models.BookmarkCollection{UserID: userID, Name: req.Name}
+	err := h.db.QueryRow(`
+		INSERT INTO bookmark_collections (user_id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, name) DO NOTHING
+		RETURNING id, created_at`,
+		userID, req.Name).Scan(&collection.ID, &collection.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have a collection with this name"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// DeleteBookmarkCollection deletes a collection. Its bookmarks are kept,
+// outside any collection.
+func (h *Handler) DeleteBookmarkCollection(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	collectionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM bookmark_collections WHERE id = $1 AND user_id = $2", collectionID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+}
+
+// ownsCollection reports whether collectionID is one of userID's
+// collections, writing an error response if it isn't.
+func (h *Handler) ownsCollection(c *gin.Context, userID, collectionID int) bool {
+	var exists bool
+	err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM bookmark_collections WHERE id = $1 AND user_id = $2)",
+		collectionID, userID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return false
+	}
+	return true
+}
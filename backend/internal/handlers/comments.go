@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetComments lists the top-level comments on a post, oldest first. Replies
+// are fetched per comment with GetCommentReplies.
+func (h *Handler) GetComments(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	h.listComments(c, commentsScope(postID), "c.post_id = $1 AND c.parent_id IS NULL", "$1", postID)
+}
+
+// GetCommentReplies lists the direct replies to a comment, oldest first.
+func (h *Handler) GetCommentReplies(c *gin.Context) {
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	h.listComments(c, commentRepliesScope(commentID), "c.parent_id = $1",
+		"(SELECT post_id FROM comments WHERE id = $1)", commentID)
+}
+
+// listComments responds with a page of the comments matching where, which
+// compares against $1, or with a 404 if their post (the SQL expression post,
+// also in terms of $1) is deleted, its author is suspended, or the author and
+// viewer have blocked each other. Comments by users blocked either way are
+// left out. Threads read oldest first, so unlike other lists pages continue
+// after the cursor's position rather than before it. As with notifications,
+// the body is a plain array and the next cursor travels in the X-Next-Cursor
+// header.
+func (h *Handler) listComments(c *gin.Context, scope, where, post string, id int) {
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
+	}
+
+	var visible bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(
+		    SELECT 1 FROM posts p
+		    JOIN users u ON p.user_id = u.id
+		    WHERE p.id = `+post+` AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2")+`
+		)`,
+		id, c.GetInt("user_id")).Scan(&visible)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comments"})
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	afterCreatedAt, afterID := pg.afterArgs()
+
+	rows, err := h.db.Query(`
+		SELECT `+commentColumns+`,
+		       EXISTS(SELECT 1 FROM comment_likes WHERE comment_id = c.id AND user_id = $6) as is_liked
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE `+where+` AND NOT `+blockedSQL("$6")+`
+		  AND ($2::timestamp IS NULL OR (c.created_at, c.id) > ($2::timestamp, $3::int))
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $4 OFFSET $5`,
+		id, afterCreatedAt, afterID, pg.limit+1, pg.offset, c.GetInt("user_id"))
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comments"})
+		return
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+
+	if len(comments) > pg.limit {
+		comments = comments[:pg.limit]
+		last := comments[len(comments)-1]
+		c.Header("X-Next-Cursor", h.nextCursor(scope, true, last.CreatedAt, last.ID))
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// getComment loads a single comment as seen by viewerID.
+func (h *Handler) getComment(commentID, viewerID int) (*models.Comment, error) {
+	return scanComment(h.db.QueryRow(`
+		SELECT `+commentColumns+`,
+		       EXISTS(SELECT 1 FROM comment_likes WHERE comment_id = c.id AND user_id = $2) as is_liked
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.id = $1`,
+		commentID, viewerID))
+}
+
+// commentColumns selects a comment and its author from comments c joined to
+// users u, in the order scanComment reads them. Queries follow it with an
+// is_liked column.
+const commentColumns = `c.id, c.post_id, c.user_id, c.parent_id, c.content, c.mentions,
+		       c.replies_count, c.likes_count, c.created_at, c.updated_at, c.edited_at,
+		       u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.created_at, u.updated_at`
+
+func scanComment(row scanner) (*models.Comment, error) {
+	var comment models.Comment
+	var user models.User
+
+	err := row.Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.ParentID, &comment.Content,
+		&comment.Mentions, &comment.RepliesCount, &comment.LikesCount,
+		&comment.CreatedAt, &comment.UpdatedAt, &comment.EditedAt,
+		&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+		&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+		&comment.IsLiked)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Edited = comment.EditedAt != nil
+	comment.User = &user
+	return &comment, nil
+}
+
+// CreateComment adds a comment to a post, or a reply to one of its
+// comments when parent_id is set.
+func (h *Handler) CreateComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Check if post exists and get owner
+	var postOwnerID int
+	err = h.db.QueryRow("SELECT user_id FROM posts WHERE id = $1 AND deleted_at IS NULL", postID).Scan(&postOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if h.rejectBlocked(c, userID, postOwnerID) {
+		return
+	}
+
+	// Replies must be to a comment on the same post
+	var parentAuthorID int
+	if req.ParentID != nil {
+		err = h.db.QueryRow("SELECT user_id FROM comments WHERE id = $1 AND post_id = $2",
+			*req.ParentID, postID).Scan(&parentAuthorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		if h.rejectBlocked(c, userID, parentAuthorID) {
+			return
+		}
+	}
+
+	mentions, err := h.resolveMentions(userID, req.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+
+	var comment models.Comment
+	err = h.db.QueryRow(`
+		INSERT INTO comments (post_id, user_id, parent_id, content, mentions)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, post_id, user_id, parent_id, content, mentions, replies_count, created_at, updated_at`,
+		postID, userID, req.ParentID, req.Content, mentions,
+	).Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.ParentID, &comment.Content,
+		&comment.Mentions, &comment.RepliesCount, &comment.CreatedAt, &comment.UpdatedAt)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+
+	// Get user info for the comment
+	user, err := h.getUserWithCounts(userID, userID)
+	if err == nil {
+		comment.User = user
+	}
+
+	// Each user gets one notification for a comment: a mention if they were
+	// mentioned, a reply if it answers them, and otherwise a comment if it's
+	// on their post.
+	notified := h.notifyMentions(comment.Mentions, userID, postID, nil)
+
+	if req.ParentID != nil && parentAuthorID != userID && !notified[parentAuthorID] {
+		h.createNotification(parentAuthorID, models.NotificationReply, userID, &postID)
+		notified[parentAuthorID] = true
+	}
+
+	// Create notification if not self-comment
+	if postOwnerID != userID && !notified[postOwnerID] {
+		h.createNotification(postOwnerID, models.NotificationComment, userID, &postID)
+	}
+
+	// Clear cache
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment lets the author change a comment's content. Edited comments
+// are marked with edited_at.
+func (h *Handler) UpdateComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var req models.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var authorID, postID int
+	var content string
+	var mentions models.Mentions
+	err = h.db.QueryRow(`
+		SELECT c.user_id, c.post_id, c.content, c.mentions
+		FROM comments c
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.id = $1 AND p.deleted_at IS NULL`,
+		commentID).Scan(&authorID, &postID, &content, &mentions)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if authorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own comments"})
+		return
+	}
+
+	if req.Content == content {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to change"})
+		return
+	}
+
+	newMentions, err := h.resolveMentions(userID, req.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		UPDATE comments SET content = $1, mentions = $2,
+		       edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3`,
+		req.Content, newMentions, commentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	h.notifyMentions(newMentions, userID, postID, mentionedUsers(mentions))
+
+	comment, err := h.getComment(commentID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment removes a comment along with its replies. The comment's
+// author and the owner of the post it's on can delete it; the comments_count
+// trigger runs for every row removed, replies included.
+func (h *Handler) DeleteComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var authorID, postID, postOwnerID int
+	err = h.db.QueryRow(`
+		SELECT c.user_id, c.post_id, p.user_id
+		FROM comments c
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.id = $1 AND p.deleted_at IS NULL`,
+		commentID).Scan(&authorID, &postID, &postOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if authorID != userID && postOwnerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't delete this comment"})
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM comments WHERE id = $1", commentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	// Clear cache
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
+
+func (h *Handler) LikeComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	// Check if comment exists and get its author and the post's
+	var authorID, postID, postOwnerID int
+	err = h.db.QueryRow(`
+		SELECT c.user_id, c.post_id, p.user_id
+		FROM comments c
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.id = $1 AND p.deleted_at IS NULL`,
+		commentID).Scan(&authorID, &postID, &postOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if h.rejectBlocked(c, userID, authorID) || h.rejectBlocked(c, userID, postOwnerID) {
+		return
+	}
+
+	// Insert like, notifying only the first time
+	result, err := h.db.Exec("INSERT INTO comment_likes (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		commentID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like comment"})
+		return
+	}
+
+	// Create notification if not self-like
+	if added, _ := result.RowsAffected(); added > 0 && authorID != userID {
+		h.createNotification(authorID, models.NotificationCommentLike, userID, &postID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment liked"})
+}
+
+func (h *Handler) UnlikeComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	commentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM comment_likes WHERE comment_id = $1 AND user_id = $2", commentID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment unliked"})
+}
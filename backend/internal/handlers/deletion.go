@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Deleted posts are hidden straight away but kept for cfg.PostRetentionDays
+// so notifications and moderation can still refer to them. The purge then
+// removes the rows along with their likes, comments, revisions and uploads.
+const (
+	postPurgeInterval  = time.Hour
+	postPurgeBatchSize = 100
+)
+
+// DeletePost soft-deletes a post. Authors can delete their own posts and
+// moderators can delete anyone's.
+func (h *Handler) DeletePost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var ownerID int
+	var content string
+	var mediaURLs models.MediaURLs
+	err = tx.QueryRow(`
+		SELECT user_id, content, media_urls
+		FROM posts WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`,
+		postID).Scan(&ownerID, &content, &mediaURLs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	role := c.GetString("role")
+	isStaff := role == models.RoleAdmin || role == models.RoleModerator
+	if ownerID != userID && !isStaff {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own posts"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1", postID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		return
+	}
+
+	if ownerID != userID {
+		if err := writeAudit(tx, userID, auditPostDelete, "post", postID, gin.H{
+			"owner_id":   ownerID,
+			"content":    content,
+			"media_urls": mediaURLs,
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.postRemoved(postID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
+}
+
+// postRemoved drops the cached copy of a deleted post and tells connected
+// clients to take it off screen. Timelines still hold the ID, but
+// hydratePosts skips deleted posts.
+func (h *Handler) postRemoved(postID int) {
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	h.hub.Broadcast(map[string]interface{}{
+		"type": "post_deleted",
+		"data": gin.H{"post_id": postID},
+	})
+}
+
+// removedPost is the placeholder shown where a deleted post is referenced.
+func removedPost(postID int) *models.Post {
+	return &models.Post{
+		ID:      postID,
+		Content: "Post removed",
+		Deleted: true,
+	}
+}
+
+// RunPostPurge hard-deletes posts that were deleted more than
+// cfg.PostRetentionDays ago, checking every postPurgeInterval.
+func (h *Handler) RunPostPurge() {
+	ticker := time.NewTicker(postPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			purged, err := h.purgeDeletedPosts(time.Now().AddDate(0, 0, -h.cfg.PostRetentionDays))
+			if err != nil {
+				log.Printf("Failed to purge deleted posts: %v", err)
+				break
+			}
+			if purged < postPurgeBatchSize {
+				break
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// purgeDeletedPosts removes one batch of posts deleted before cutoff and
+// returns how many went. Likes, comments and revisions go with them through
+// their ON DELETE CASCADE keys; uploaded media is removed from disk once the
+// rows are gone.
+func (h *Handler) purgeDeletedPosts(cutoff time.Time) (int, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id FROM posts
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		cutoff, postPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	rows, err = tx.Query(`
+		SELECT media_urls FROM posts WHERE id = ANY($1)
+		UNION ALL
+		SELECT media_urls FROM post_revisions WHERE post_id = ANY($1)`,
+		pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	var media []string
+	for rows.Next() {
+		var urls models.MediaURLs
+		if rows.Scan(&urls) == nil {
+			media = append(media, urls...)
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.Exec("DELETE FROM posts WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, url := range media {
+		h.removeUpload(url)
+	}
+
+	return len(ids), nil
+}
+
+// removeUpload deletes the file behind an /uploads/ URL unless another post
+// or revision, or someone's avatar, still uses it.
+func (h *Handler) removeUpload(url string) {
+	if !strings.HasPrefix(url, "/uploads/") {
+		return
+	}
+
+	var inUse bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM posts WHERE media_urls ? $1)
+		    OR EXISTS(SELECT 1 FROM post_revisions WHERE media_urls ? $1)
+		    OR EXISTS(SELECT 1 FROM users WHERE avatar = $1)`,
+		url).Scan(&inUse)
+	if err != nil || inUse {
+		return
+	}
+
+	path := filepath.Join(h.cfg.UploadPath, filepath.Base(url))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove upload %s: %v", path, err)
+		return
+	}
+	h.db.Exec("DELETE FROM uploads WHERE url = $1", url)
+}
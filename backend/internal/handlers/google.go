@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"pulsefeed-backend/internal/google"
+	"pulsefeed-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errGoogleAccountConflict = errors.New("google account conflict")
+
+func (h *Handler) GoogleAuth(c *gin.Context) {
+	var req models.GoogleAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := h.verifyGoogleToken(c, req.IDToken)
+	if !ok {
+		return
+	}
+
+	user, created, err := h.findOrCreateGoogleUser(claims)
+	if err != nil {
+		if errors.Is(err, errGoogleAccountConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists. Sign in and link Google from your profile"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in with Google"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	h.completeSignIn(c, user, status)
+}
+
+func (h *Handler) LinkGoogleAccount(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.GoogleAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := h.verifyGoogleToken(c, req.IDToken)
+	if !ok {
+		return
+	}
+
+	var linkedUserID int
+	err := h.db.QueryRow("SELECT id FROM users WHERE google_id = $1", claims.Subject).Scan(&linkedUserID)
+	if err == nil && linkedUserID != userID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Google account is already linked to another user"})
+		return
+	}
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	_, err = h.db.Exec("UPDATE users SET google_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		claims.Subject, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link Google account"})
+		return
+	}
+
+	user, err := h.getUserWithCounts(userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// verifyGoogleToken writes the error response itself and reports whether the
+// caller should continue.
+func (h *Handler) verifyGoogleToken(c *gin.Context, idToken string) (*google.Claims, bool) {
+	claims, err := h.google.Verify(idToken)
+	if err != nil {
+		if errors.Is(err, google.ErrNotConfigured) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Google sign-in is not configured"})
+			return nil, false
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Google ID token"})
+		return nil, false
+	}
+	return claims, true
+}
+
+// findOrCreateGoogleUser resolves the Google subject to a user. An existing
+// password account is linked automatically only when Google has verified the
+// email address; otherwise the user must link from their profile.
+func (h *Handler) findOrCreateGoogleUser(claims *google.Claims) (*models.User, bool, error) {
+	var userID int
+	err := h.db.QueryRow("SELECT id FROM users WHERE google_id = $1", claims.Subject).Scan(&userID)
+	if err == nil {
+		user, err := h.getUserWithCounts(userID, userID)
+		return user, false, err
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	if claims.Email != "" {
+		var existingGoogleID sql.NullString
+		err = h.db.QueryRow("SELECT id, google_id FROM users WHERE LOWER(email) = LOWER($1)",
+			claims.Email).Scan(&userID, &existingGoogleID)
+		if err == nil {
+			if !claims.EmailVerified || existingGoogleID.Valid {
+				return nil, false, errGoogleAccountConflict
+			}
+
+			_, err = h.db.Exec("UPDATE users SET google_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+				claims.Subject, userID)
+			if err != nil {
+				return nil, false, err
+			}
+
+			user, err := h.getUserWithCounts(userID, userID)
+			return user, false, err
+		}
+		if err != sql.ErrNoRows {
+			return nil, false, err
+		}
+	}
+
+	username, err := h.availableUsername(claims.Email)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fullName := claims.Name
+	if fullName == "" {
+		fullName = username
+	}
+
+	var user models.User
+	err = h.db.QueryRow(`
+		INSERT INTO users (username, email, full_name, avatar, is_verified, google_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
+		RETURNING id, username, COALESCE(email, ''), full_name, bio, avatar, is_verified, created_at, updated_at`,
+		username, claims.Email, fullName, claims.Picture, claims.EmailVerified, claims.Subject,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+		&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &user, true, nil
+}
+
+// availableUsername derives a unique username from the local part of an
+// email, falling back to a generic "user" prefix when there is none.
+func (h *Handler) availableUsername(email string) (string, error) {
+	base := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+	base = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return -1
+	}, base)
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		var exists bool
+		err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)", candidate).Scan(&exists)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, rand.Intn(10000))
+	}
+
+	return "", errors.New("could not find an available username")
+}
@@ -2,31 +2,58 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"pulsefeed-backend/internal/config"
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/google"
+	"pulsefeed-backend/internal/mail"
+	"pulsefeed-backend/internal/middleware"
 	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/ranking"
 	"pulsefeed-backend/internal/redis"
+	"pulsefeed-backend/internal/sms"
+	"pulsefeed-backend/internal/token"
 	"pulsefeed-backend/internal/websocket"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	gorillaws "github.com/gorilla/websocket"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Handler struct {
-	db    *sql.DB
-	redis *redis.Client
-	hub   *websocket.Hub
+	cfg     *config.Config
+	db      *sql.DB
+	redis   *redis.Client
+	hub     *websocket.Hub
+	tokens  *token.Service
+	google  *google.Verifier
+	sms     sms.Sender
+	mailer  mail.Mailer
+	cursors *cursor.Codec
+	rankers *ranking.Experiment
 }
 
-func New(db *sql.DB, redisClient *redis.Client, hub *websocket.Hub) *Handler {
+func New(cfg *config.Config, db *sql.DB, redisClient *redis.Client, hub *websocket.Hub, tokens *token.Service,
+	googleVerifier *google.Verifier, smsSender sms.Sender, mailer mail.Mailer, cursors *cursor.Codec,
+	rankers *ranking.Experiment) *Handler {
 	return &Handler{
-		db:    db,
-		redis: redisClient,
-		hub:   hub,
+		cfg:     cfg,
+		db:      db,
+		redis:   redisClient,
+		hub:     hub,
+		tokens:  tokens,
+		google:  googleVerifier,
+		sms:     smsSender,
+		mailer:  mailer,
+		cursors: cursors,
+		rankers: rankers,
 	}
 }
 
@@ -73,8 +100,16 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	// Registration succeeds even if the mail can't go out; the user can resend.
+	// It goes out in the background so a slow mail server can't hold up sign-up.
+	go func(user models.User) {
+		if err := h.sendVerificationEmail(&user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}(user)
+
 	// Generate tokens
-	accessToken, refreshToken, err := h.generateTokens(user.ID)
+	accessToken, refreshToken, err := h.generateTokens(c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
@@ -97,7 +132,7 @@ func (h *Handler) Login(c *gin.Context) {
 	var user models.User
 	var hashedPassword string
 	err := h.db.QueryRow(`
-		SELECT id, username, email, password_hash, full_name, bio, avatar, is_verified, created_at, updated_at
+		SELECT id, username, COALESCE(email, ''), COALESCE(password_hash, ''), full_name, bio, avatar, is_verified, created_at, updated_at
 		FROM users WHERE username = $1 OR email = $1`,
 		req.Username,
 	).Scan(&user.ID, &user.Username, &user.Email, &hashedPassword, &user.FullName,
@@ -118,28 +153,139 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate tokens
-	accessToken, refreshToken, err := h.generateTokens(user.ID)
+	h.completeSignIn(c, &user, http.StatusOK)
+}
+
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := h.parseRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var familyID string
+	var expiresAt time.Time
+	var rotatedAt, revokedAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT user_id, family_id, expires_at, rotated_at, revoked_at
+		FROM refresh_tokens WHERE token_id = $1
+		FOR UPDATE`,
+		claims.ID,
+	).Scan(&userID, &familyID, &expiresAt, &rotatedAt, &revokedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// A token that was already rotated is being replayed, so the whole family
+	// (and the session it belongs to) is considered compromised.
+	if rotatedAt.Valid || revokedAt.Valid {
+		if revoked, err := revokeSessions(tx, userID, familyID, ""); err == nil && tx.Commit() == nil {
+			h.afterSessionsRevoked(revoked)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token expired"})
+		return
+	}
+
+	if h.rejectSuspended(c, userID) {
+		return
+	}
+
+	// The refresh token family is the session. Families issued before sessions
+	// existed get their session row on first refresh.
+	var sessionRevokedAt sql.NullTime
+	err = tx.QueryRow("SELECT revoked_at FROM sessions WHERE id = $1 FOR UPDATE", familyID).Scan(&sessionRevokedAt)
+	if err == sql.ErrNoRows {
+		err = insertSession(tx, familyID, c, userID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if sessionRevokedAt.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE refresh_tokens SET rotated_at = CURRENT_TIMESTAMP WHERE token_id = $1",
+		claims.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1",
+		familyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	accessToken, refreshToken, err := h.issueTokens(tx, userID, familyID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	user, err := h.getUserWithCounts(userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.AuthResponse{
-		User:         &user,
+		User:         user,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	})
 }
 
-func (h *Handler) RefreshToken(c *gin.Context) {
-	// Implementation for refresh token
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented yet"})
-}
+func (h *Handler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-func (h *Handler) GoogleAuth(c *gin.Context) {
-	// Implementation for Google OAuth
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "Not implemented yet"})
+	claims, err := h.parseRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	if _, err := h.revokeSession(claims.UserID, claims.FamilyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
 }
 
 // User handlers
@@ -210,27 +356,67 @@ func (h *Handler) GetUserProfile(c *gin.Context) {
 }
 
 // Helper functions
-func (h *Handler) generateTokens(userID int) (string, string, error) {
-	// Access token (15 minutes)
-	accessClaims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Minute * 15).Unix(),
-		"iat":     time.Now().Unix(),
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// generateTokens starts a new session for the requesting device and issues
+// its first token pair.
+func (h *Handler) generateTokens(c *gin.Context, userID int) (string, string, error) {
+	sessionID, err := createSession(h.db, c, userID)
+	if err != nil {
+		return "", "", err
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString([]byte("your-super-secret-jwt-key"))
+	return h.issueTokens(h.db, userID, sessionID)
+}
+
+// issueTokens signs an access/refresh pair for sessionID and persists the
+// refresh token as the newest member of the session's token family.
+func (h *Handler) issueTokens(db execer, userID int, sessionID string) (string, string, error) {
+	var role string
+	if err := h.db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&role); err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+
+	// Access token (15 minutes)
+	accessTokenString, err := h.tokens.Sign(&token.Claims{
+		UserID:    userID,
+		Type:      token.TypeAccess,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute * 15)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
 	if err != nil {
 		return "", "", err
 	}
 
 	// Refresh token (7 days)
-	refreshClaims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
-		"iat":     time.Now().Unix(),
+	tokenID := uuid.New().String()
+	expiresAt := now.Add(time.Hour * 24 * 7)
+	refreshTokenString, err := h.tokens.Sign(&token.Claims{
+		UserID:    userID,
+		Type:      token.TypeRefresh,
+		SessionID: sessionID,
+		FamilyID:  sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return "", "", err
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte("your-super-secret-jwt-key"))
+
+	_, err = db.Exec(`
+		INSERT INTO refresh_tokens (token_id, family_id, user_id, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		tokenID, sessionID, userID, expiresAt)
 	if err != nil {
 		return "", "", err
 	}
@@ -238,16 +424,29 @@ func (h *Handler) generateTokens(userID int) (string, string, error) {
 	return accessTokenString, refreshTokenString, nil
 }
 
+// parseRefreshToken validates a refresh JWT and checks it names its token
+// and family.
+func (h *Handler) parseRefreshToken(tokenString string) (*token.Claims, error) {
+	claims, err := h.tokens.Parse(tokenString, token.TypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID == "" || claims.FamilyID == "" {
+		return nil, token.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
 func (h *Handler) getUserWithCounts(userID, currentUserID int) (*models.User, error) {
 	var user models.User
 	
 	// Get user with counts and follow status
 	err := h.db.QueryRow(`
-		SELECT u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified, 
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified, 
 		       u.created_at, u.updated_at,
-		       (SELECT COUNT(*) FROM follows WHERE following_id = u.id) as followers_count,
-		       (SELECT COUNT(*) FROM follows WHERE follower_id = u.id) as following_count,
-		       (SELECT COUNT(*) FROM posts WHERE user_id = u.id) as posts_count,
+		       u.followers_count, u.following_count, u.posts_count,
 		       CASE WHEN $2 != u.id THEN 
 		           EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id)
 		       ELSE false END as is_following
@@ -263,28 +462,28 @@ func (h *Handler) getUserWithCounts(userID, currentUserID int) (*models.User, er
 // WebSocket handler
 func (h *Handler) HandleWebSocket(c *gin.Context) {
 	// Get user ID from token
-	token := c.Query("token")
-	if token == "" {
+	tokenString := c.Query("token")
+	if tokenString == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token required"})
 		return
 	}
 
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte("your-super-secret-jwt-key"), nil
-	})
-
-	if err != nil || !parsedToken.Valid {
+	claims, err := h.tokens.Parse(tokenString, token.TypeAccess)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
 	}
 
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+	if err := h.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+		if errors.Is(err, middleware.ErrAccountSuspended) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
 		return
 	}
 
-	userID := int(claims["user_id"].(float64))
+	userID := claims.UserID
 
 	// Upgrade connection
 	upgrader := gorillaws.Upgrader{
@@ -298,5 +497,5 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	h.hub.HandleWebSocket(conn, userID)
+	h.hub.HandleWebSocket(conn, userID, claims.SessionID)
 }
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pulsefeed-backend/internal/entities"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Trending counts tag uses in Redis sorted sets, one per
+// hashtagBucketSize of time. A window is the sum of its most recent buckets,
+// so it slides forward one bucket at a time.
+const (
+	hashtagBucketSize = 5 * time.Minute
+	// longestTrendingWindow bounds how long a bucket has to be kept.
+	longestTrendingWindow = 24 * time.Hour
+	trendingCacheTTL      = time.Minute
+)
+
+var trendingWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+// indexHashtags points post_hashtags for postID at the tags in content,
+// creating any tags seen for the first time.
+func indexHashtags(db execer, postID int, content string) error {
+	tags := entities.Hashtags(content)
+
+	if len(tags) > 0 {
+		if _, err := db.Exec(`
+			INSERT INTO hashtags (tag)
+			SELECT unnest($1::text[])
+			ON CONFLICT (tag) DO NOTHING`,
+			pq.Array(tags)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.Exec(`
+		DELETE FROM post_hashtags
+		WHERE post_id = $1 AND hashtag_id NOT IN (SELECT id FROM hashtags WHERE tag = ANY($2))`,
+		postID, pq.Array(tags)); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO post_hashtags (post_id, hashtag_id)
+		SELECT $1, id FROM hashtags WHERE tag = ANY($2)
+		ON CONFLICT DO NOTHING`,
+		postID, pq.Array(tags))
+	return err
+}
+
+// recordHashtagUses counts tags towards trending in the current bucket.
+func (h *Handler) recordHashtagUses(tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	bucket := time.Now().Unix() / int64(hashtagBucketSize/time.Second)
+	key := redis.HashtagBucketKey(bucket)
+	if err := h.redis.IncrMembers(key, tags, longestTrendingWindow+hashtagBucketSize); err != nil {
+		log.Printf("Failed to record hashtag uses: %v", err)
+	}
+}
+
+// addedHashtags returns the tags in newContent that weren't in oldContent.
+func addedHashtags(oldContent, newContent string) []string {
+	previous := make(map[string]bool)
+	for _, tag := range entities.Hashtags(oldContent) {
+		previous[tag] = true
+	}
+
+	added := []string{}
+	for _, tag := range entities.Hashtags(newContent) {
+		if !previous[tag] {
+			added = append(added, tag)
+		}
+	}
+	return added
+}
+
+func (h *Handler) GetHashtagPosts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	tag, ok := entities.NormalizeHashtag(c.Param("tag"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hashtag"})
+		return
+	}
+
+	scope := hashtagScope(tag)
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
+	}
+	afterCreatedAt, afterID := pg.afterArgs()
+
+	rows, err := h.db.Query(`
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$2")+`
+		FROM hashtags t
+		JOIN post_hashtags ph ON ph.hashtag_id = t.id
+		JOIN posts p ON p.id = ph.post_id
+		JOIN users u ON p.user_id = u.id
+		WHERE t.tag = $1 AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2")+`
+		  AND ($5::timestamp IS NULL OR (p.created_at, p.id) < ($5::timestamp, $6::int))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3 OFFSET $4`,
+		tag, userID, pg.limit+1, pg.offset, afterCreatedAt, afterID)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
+		return
+	}
+	defer rows.Close()
+
+	h.respondFeedPage(c, scope, h.scanFeedPosts(rows), pg.limit)
+}
+
+func (h *Handler) GetTrendingHashtags(c *gin.Context) {
+	window := c.DefaultQuery("window", "24h")
+	duration, ok := trendingWindows[window]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown trending window"})
+		return
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	current := time.Now().Unix() / int64(hashtagBucketSize/time.Second)
+	buckets := int64(duration / hashtagBucketSize)
+	keys := make([]string, 0, buckets)
+	for bucket := current - buckets + 1; bucket <= current; bucket++ {
+		keys = append(keys, redis.HashtagBucketKey(bucket))
+	}
+
+	top, err := h.redis.TopOfUnion(redis.TrendingHashtagsKey(window, current), keys, int64(limit), trendingCacheTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trending hashtags"})
+		return
+	}
+
+	hashtags := make([]models.TrendingHashtag, 0, len(top))
+	for _, member := range top {
+		hashtags = append(hashtags, models.TrendingHashtag{Tag: member.Member, Uses: int(member.Score)})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"window":   window,
+		"hashtags": hashtags,
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"strings"
+
+	"pulsefeed-backend/internal/entities"
+	"pulsefeed-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// resolveMentions finds the @usernames in content written by authorID and
+// looks them up. Usernames match case-insensitively; ones that don't exist,
+// are suspended, or have a block with the author either way are left as
+// plain text.
+func (h *Handler) resolveMentions(authorID int, content string) (models.Mentions, error) {
+	found := entities.Mentions(content)
+	if len(found) == 0 {
+		return models.Mentions{}, nil
+	}
+
+	usernames := make([]string, 0, len(found))
+	for _, mention := range found {
+		usernames = append(usernames, strings.ToLower(mention.Username))
+	}
+
+	rows, err := h.db.Query(`
+		SELECT u.id, u.username
+		FROM users u
+		WHERE LOWER(u.username) = ANY($1) AND `+notSuspendedSQL+`
+		  AND NOT `+blockedSQL("$2"),
+		pq.Array(usernames), authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]models.Mention)
+	for rows.Next() {
+		var user models.Mention
+		if rows.Scan(&user.UserID, &user.Username) == nil {
+			users[strings.ToLower(user.Username)] = user
+		}
+	}
+
+	mentions := models.Mentions{}
+	for _, mention := range found {
+		user, ok := users[strings.ToLower(mention.Username)]
+		if !ok {
+			continue
+		}
+		user.Start, user.End = mention.Start, mention.End
+		mentions = append(mentions, user)
+	}
+	return mentions, nil
+}
+
+// notifyMentions sends one mention notification per user mentioned, leaving
+// out the author and anyone in skip (for instance users notified by an
+// earlier version of an edited post). It returns who was notified.
+func (h *Handler) notifyMentions(mentions models.Mentions, actorID, postID int, skip map[int]bool) map[int]bool {
+	notified := make(map[int]bool)
+	for _, mention := range mentions {
+		if mention.UserID == actorID || skip[mention.UserID] || notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+		h.createNotification(mention.UserID, models.NotificationMention, actorID, &postID)
+	}
+	return notified
+}
+
+// mentionedUsers returns the IDs of the users in mentions.
+func mentionedUsers(mentions models.Mentions) map[int]bool {
+	ids := make(map[int]bool, len(mentions))
+	for _, mention := range mentions {
+		ids[mention.UserID] = true
+	}
+	return ids
+}
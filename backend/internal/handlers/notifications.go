@@ -12,33 +12,26 @@ import (
 func (h *Handler) GetNotifications(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	limit := 20
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
-			limit = parsed
-		}
-	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	scope := notificationsScope(userID)
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
 	}
+	afterCreatedAt, afterID := pg.afterArgs()
 
 	rows, err := h.db.Query(`
 		SELECT n.id, n.user_id, n.type, n.actor_id, n.post_id, n.is_read, n.created_at,
-		       u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
 		       u.created_at, u.updated_at,
-		       CASE WHEN n.post_id IS NOT NULL THEN
-		           (SELECT content FROM posts WHERE id = n.post_id)
-		       ELSE NULL END as post_content
+		       CASE WHEN p.deleted_at IS NULL THEN p.content END as post_content
 		FROM notifications n
 		JOIN users u ON n.actor_id = u.id
+		LEFT JOIN posts p ON p.id = n.post_id
 		WHERE n.user_id = $1
-		ORDER BY n.created_at DESC
+		  AND ($4::timestamp IS NULL OR (n.created_at, n.id) < ($4::timestamp, $5::int))
+		ORDER BY n.created_at DESC, n.id DESC
 		LIMIT $2 OFFSET $3`,
-		userID, limit, offset)
+		userID, pg.limit+1, pg.offset, afterCreatedAt, afterID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
@@ -64,16 +57,30 @@ func (h *Handler) GetNotifications(c *gin.Context) {
 		}
 
 		notification.Actor = &actor
-		if postContent != nil && notification.PostID != nil {
-			notification.Post = &models.Post{
-				ID:      *notification.PostID,
-				Content: *postContent,
+		if notification.PostID != nil {
+			// Deleted and purged posts keep their notifications, which
+			// just say the post is gone.
+			if postContent != nil {
+				notification.Post = &models.Post{
+					ID:      *notification.PostID,
+					Content: *postContent,
+				}
+			} else {
+				notification.Post = removedPost(*notification.PostID)
 			}
 		}
 
 		notifications = append(notifications, &notification)
 	}
 
+	// The body stays a plain array for existing clients, so the cursor for
+	// the next page travels in a header.
+	if len(notifications) > pg.limit {
+		notifications = notifications[:pg.limit]
+		last := notifications[len(notifications)-1]
+		c.Header("X-Next-Cursor", h.nextCursor(scope, true, last.CreatedAt, last.ID))
+	}
+
 	c.JSON(http.StatusOK, notifications)
 }
 
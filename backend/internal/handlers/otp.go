@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	otpTTL         = 5 * time.Minute
+	otpMaxAttempts = 5
+	otpMaxSends    = 3
+	otpSendWindow  = time.Hour
+)
+
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type otpRecord struct {
+	Hash string `json:"hash"`
+}
+
+func (h *Handler) SendOTP(c *gin.Context) {
+	var req models.SendOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phoneNumber, ok := normalizePhoneNumber(req.PhoneNumber)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+
+	sends, err := h.redis.Incr(redis.OTPSendsKey(phoneNumber), otpSendWindow)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
+		return
+	}
+	if sends > otpMaxSends {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many OTP requests, try again later"})
+		return
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OTP"})
+		return
+	}
+
+	// A new code resets the attempt budget for the previous one.
+	if err := h.redis.Set(redis.OTPKey(phoneNumber), otpRecord{Hash: string(hash)}, otpTTL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send OTP"})
+		return
+	}
+	h.redis.Delete(redis.OTPAttemptsKey(phoneNumber))
+
+	message := fmt.Sprintf("Your PulseFeed verification code is %s. It expires in %d minutes.",
+		code, int(otpTTL.Minutes()))
+	if err := h.sms.Send(phoneNumber, message); err != nil {
+		h.redis.Delete(redis.OTPKey(phoneNumber))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send OTP"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "OTP sent"})
+}
+
+func (h *Handler) VerifyOTP(c *gin.Context) {
+	var req models.VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phoneNumber, ok := normalizePhoneNumber(req.PhoneNumber)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid phone number"})
+		return
+	}
+
+	if !h.checkOTP(c, phoneNumber, req.OTP) {
+		return
+	}
+
+	user, created, err := h.findOrCreatePhoneUser(phoneNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	h.completeSignIn(c, user, status)
+}
+
+// checkOTP uses up the code sent to phoneNumber if otp matches it, writing an
+// error response and returning false if it doesn't.
+func (h *Handler) checkOTP(c *gin.Context, phoneNumber, otp string) bool {
+	attempts, err := h.redis.Incr(redis.OTPAttemptsKey(phoneNumber), otpTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify OTP"})
+		return false
+	}
+	if attempts > otpMaxAttempts {
+		h.redis.Delete(redis.OTPKey(phoneNumber))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, request a new OTP"})
+		return false
+	}
+
+	var record otpRecord
+	if err := h.redis.Get(redis.OTPKey(phoneNumber), &record); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "OTP expired or not found"})
+		return false
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(record.Hash), []byte(otp)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP"})
+		return false
+	}
+
+	// Codes are single-use.
+	h.redis.Delete(redis.OTPKey(phoneNumber))
+	h.redis.Delete(redis.OTPAttemptsKey(phoneNumber))
+	return true
+}
+
+func (h *Handler) findOrCreatePhoneUser(phoneNumber string) (*models.User, bool, error) {
+	var userID int
+	err := h.db.QueryRow("SELECT id FROM users WHERE phone_number = $1", phoneNumber).Scan(&userID)
+	if err == nil {
+		user, err := h.getUserWithCounts(userID, userID)
+		return user, false, err
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	username, err := h.availableUsername("")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var user models.User
+	err = h.db.QueryRow(`
+		INSERT INTO users (username, phone_number, full_name)
+		VALUES ($1, $2, $3)
+		RETURNING id, username, COALESCE(email, ''), full_name, bio, avatar, is_verified, created_at, updated_at`,
+		username, phoneNumber, username,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+		&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &user, true, nil
+}
+
+// normalizePhoneNumber strips common formatting and checks for E.164.
+func normalizePhoneNumber(phoneNumber string) (string, bool) {
+	normalized := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phoneNumber))
+
+	return normalized, phoneNumberPattern.MatchString(normalized)
+}
+
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pulsefeed-backend/internal/cursor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// page is a parsed list request. Lists are ordered by (created_at DESC, id
+// DESC) and continue from after when a cursor was given. offset is the
+// deprecated way to page and is only set when no cursor was sent.
+type page struct {
+	limit  int
+	offset int
+	after  *cursor.Position
+}
+
+// parsePage reads limit, cursor and offset from the query string, writing a
+// 400 and returning false if the cursor doesn't verify for scope.
+func (h *Handler) parsePage(c *gin.Context, scope string) (page, bool) {
+	p := page{limit: pageLimit(c)}
+
+	if token := c.Query("cursor"); token != "" {
+		after, err := h.cursors.Decode(scope, token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return page{}, false
+		}
+		p.after = &after
+		return p, true
+	}
+
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			p.offset = parsed
+		}
+		c.Header("Deprecation", "true")
+	}
+
+	return p, true
+}
+
+// parseScorePage is parsePage for lists ordered by (score DESC, id DESC),
+// returning the cursor's position separately since it isn't a time.
+func (h *Handler) parseScorePage(c *gin.Context, scope string) (page, *cursor.ScorePosition, bool) {
+	token := c.Query("cursor")
+	if token == "" {
+		p, ok := h.parsePage(c, scope)
+		return p, nil, ok
+	}
+
+	after, err := h.cursors.DecodeScore(scope, token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return page{}, nil, false
+	}
+	return page{limit: pageLimit(c)}, &after, true
+}
+
+func pageLimit(c *gin.Context) int {
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 50 {
+		return parsed
+	}
+	return 20
+}
+
+// first reports whether this is the first page of the list.
+func (p page) first() bool {
+	return p.after == nil && p.offset == 0
+}
+
+// afterArgs returns the keyset position as query arguments, both nil when
+// listing from the start. Queries compare against them with
+// ($n::timestamp IS NULL OR (created_at, id) < ($n::timestamp, $m::int)).
+func (p page) afterArgs() (interface{}, interface{}) {
+	if p.after == nil {
+		return nil, nil
+	}
+	return p.after.CreatedAt, p.after.ID
+}
+
+// nextCursor returns the cursor continuing after the last row of a page, or
+// "" when there are no more rows.
+func (h *Handler) nextCursor(scope string, hasMore bool, createdAt time.Time, id int) string {
+	if !hasMore {
+		return ""
+	}
+	return h.cursors.Encode(scope, cursor.Position{CreatedAt: createdAt, ID: id})
+}
+
+func feedScope(userID int) string {
+	return fmt.Sprintf("feed:%d", userID)
+}
+
+func userPostsScope(userID int) string {
+	return fmt.Sprintf("user_posts:%d", userID)
+}
+
+func notificationsScope(userID int) string {
+	return fmt.Sprintf("notifications:%d", userID)
+}
+
+func commentsScope(postID int) string {
+	return fmt.Sprintf("comments:%d", postID)
+}
+
+func commentRepliesScope(commentID int) string {
+	return fmt.Sprintf("comment_replies:%d", commentID)
+}
+
+// postLikesScope is the scope of one part of a post's likes list: the users
+// the viewer follows, then everyone else.
+func postLikesScope(postID, viewerID int, kind string, followed bool) string {
+	return fmt.Sprintf("post_likes:%d:%d:%s:%t", postID, viewerID, kind, followed)
+}
+
+func bookmarksScope(userID int, collectionID *int) string {
+	if collectionID == nil {
+		return fmt.Sprintf("bookmarks:%d", userID)
+	}
+	return fmt.Sprintf("bookmarks:%d:%d", userID, *collectionID)
+}
+
+func postSearchScope(userID int, sort, query string) string {
+	return fmt.Sprintf("post_search:%d:%s:%s", userID, sort, query)
+}
+
+func hashtagScope(tag string) string {
+	return fmt.Sprintf("hashtag:%s", tag)
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetTTL      = time.Hour
+	passwordResetMaxSends = 3
+	passwordResetWindow   = time.Hour
+)
+
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The response is the same whether or not the account exists so the
+	// endpoint can't be used to discover registered emails.
+	response := gin.H{"message": "If an account exists for that email, a reset link has been sent"}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	sends, err := h.redis.Incr(redis.PasswordResetSendsKey(email), passwordResetWindow)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset email"})
+		return
+	}
+	if sends > passwordResetMaxSends {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many reset requests, try again later"})
+		return
+	}
+
+	var userID int
+	var fullName string
+	err = h.db.QueryRow("SELECT id, full_name FROM users WHERE LOWER(email) = $1", email).Scan(&userID, &fullName)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	resetToken, err := generateResetToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate reset token"})
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`,
+		userID, sha256Hex(resetToken), time.Now().Add(passwordResetTTL))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your PulseFeed password. Use this code in the app to choose a new one:\n\n%s\n\n"+
+		"The code expires in %d minutes and can only be used once. If this wasn't you, you can ignore this email.\n",
+		fullName, resetToken, int(passwordResetTTL.Minutes()))
+	// Sent in the background so neither a slow mail server nor the time it
+	// takes gives away whether the account exists.
+	go func() {
+		if err := h.mailer.Send(email, "Reset your PulseFeed password", body); err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", userID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id`,
+		sha256Hex(strings.TrimSpace(req.Token)),
+	).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	revoked, err := setPassword(tx, userID, string(hashedPassword))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	// Any other reset tokens still in flight for this account are now stale.
+	if _, err := tx.Exec(`
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND used_at IS NULL`,
+		userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.afterSessionsRevoked(revoked)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var currentHash, googleID, phoneNumber string
+	err := h.db.QueryRow(`
+		SELECT COALESCE(password_hash, ''), COALESCE(google_id, ''), COALESCE(phone_number, '')
+		FROM users WHERE id = $1`,
+		userID).Scan(&currentHash, &googleID, &phoneNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if currentHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(currentHash), []byte(req.CurrentPassword)); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+			return
+		}
+	} else if !h.confirmFirstPassword(c, &req, googleID, phoneNumber) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	revoked, err := setPassword(tx, userID, string(hashedPassword))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	// Every session was just signed out, including this one, so hand this
+	// client a fresh session.
+	sessionID, err := createSession(tx, c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	accessToken, refreshToken, err := h.issueTokens(tx, userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.afterSessionsRevoked(revoked)
+
+	user, err := h.getUserWithCounts(userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// confirmFirstPassword checks the fresh proof of identity needed to set the
+// first password on a Google or phone account, since an access token alone
+// may have been stolen. Accounts with an email can also use the reset flow.
+// It writes an error response and returns false if the proof is missing or
+// doesn't match the account.
+func (h *Handler) confirmFirstPassword(c *gin.Context, req *models.ChangePasswordRequest, googleID, phoneNumber string) bool {
+	switch {
+	case req.GoogleIDToken != "" && googleID != "":
+		claims, ok := h.verifyGoogleToken(c, req.GoogleIDToken)
+		if !ok {
+			return false
+		}
+		if claims.Subject != googleID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Google account does not match"})
+			return false
+		}
+		return true
+
+	case req.OTP != "" && phoneNumber != "":
+		return h.checkOTP(c, phoneNumber, req.OTP)
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Confirm it's you with a Google ID token or an OTP sent to your phone, or reset your password by email"})
+	return false
+}
+
+// setPassword stores a new password hash and revokes every session, returning
+// their IDs for afterSessionsRevoked once the transaction commits.
+func setPassword(tx *sql.Tx, userID int, hashedPassword string) ([]string, error) {
+	if _, err := tx.Exec("UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2",
+		hashedPassword, userID); err != nil {
+		return nil, err
+	}
+
+	return revokeSessions(tx, userID, "", "")
+}
+
+func generateResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sha256Hex hashes high-entropy secrets such as reset tokens for storage.
+func sha256Hex(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 	"strconv"
-	"time"
 
+	"pulsefeed-backend/internal/entities"
 	"pulsefeed-backend/internal/models"
 	"pulsefeed-backend/internal/redis"
 
@@ -21,271 +22,179 @@ func (h *Handler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	var post models.Post
-	err := h.db.QueryRow(`
-		INSERT INTO posts (user_id, content, media_urls, media_type) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, user_id, content, media_urls, media_type, likes_count, comments_count, created_at, updated_at`,
-		userID, req.Content, models.MediaURLs(req.MediaURLs), req.MediaType,
-	).Scan(&post.ID, &post.UserID, &post.Content, &post.MediaURLs, &post.MediaType,
-		&post.LikesCount, &post.CommentsCount, &post.CreatedAt, &post.UpdatedAt)
+	if !h.checkPostMedia(c, userID, req.MediaURLs, nil) {
+		return
+	}
+
+	var quoted *sharedPost
+	if req.QuoteOfID != nil {
+		var ok bool
+		if quoted, ok = h.loadSharedPost(c, userID, *req.QuoteOfID); !ok {
+			return
+		}
+	}
 
+	mentions, err := h.resolveMentions(userID, req.Content)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
 
-	// Get user info for the post
-	user, err := h.getUserWithCounts(userID, userID)
-	if err == nil {
-		post.User = user
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
 	}
+	defer tx.Rollback()
 
-	// Clear feed cache for followers
-	h.clearFollowersFeedCache(userID)
-
-	// Broadcast new post via WebSocket
-	h.hub.Broadcast(map[string]interface{}{
-		"type": "new_post",
-		"data": post,
-	})
+	var quoteOfID *int
+	if quoted != nil {
+		quoteOfID = &quoted.id
+	}
 
-	c.JSON(http.StatusCreated, post)
-}
+	var postID int
+	err = tx.QueryRow(`
+		INSERT INTO posts (user_id, content, media_urls, media_type, mentions, quote_of_id) 
+		VALUES ($1, $2, $3, $4, $5, $6) 
+		RETURNING id`,
+		userID, req.Content, models.MediaURLs(req.MediaURLs), req.MediaType, mentions, quoteOfID,
+	).Scan(&postID)
 
-func (h *Handler) GetFeed(c *gin.Context) {
-	userID := c.GetInt("user_id")
-	
-	limit := 20
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
-			limit = parsed
-		}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
+		return
 	}
 
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	if err := indexHashtags(tx, postID, req.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
+		return
 	}
 
-	// Try to get from cache first
-	cacheKey := redis.FeedCacheKey(userID)
-	var cachedPosts []*models.Post
-	if offset == 0 && h.redis.Get(cacheKey, &cachedPosts) == nil && len(cachedPosts) > 0 {
-		end := limit
-		if end > len(cachedPosts) {
-			end = len(cachedPosts)
-		}
-		
-		c.JSON(http.StatusOK, models.FeedResponse{
-			Posts:   cachedPosts[:end],
-			HasMore: len(cachedPosts) > limit,
-		})
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	// Get posts from database
-	rows, err := h.db.Query(`
-		SELECT p.id, p.user_id, p.content, p.media_urls, p.media_type, 
-		       p.likes_count, p.comments_count, p.created_at, p.updated_at,
-		       u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
-		       u.created_at, u.updated_at,
-		       EXISTS(SELECT 1 FROM likes WHERE post_id = p.id AND user_id = $1) as is_liked
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		WHERE p.user_id = $1 OR p.user_id IN (
-			SELECT following_id FROM follows WHERE follower_id = $1
-		)
-		ORDER BY p.created_at DESC
-		LIMIT $2 OFFSET $3`,
-		userID, limit, offset)
+	h.recordHashtagUses(entities.Hashtags(req.Content))
+	h.notifyMentions(mentions, userID, postID, nil)
+	if quoted != nil {
+		if quoted.ownerID != userID {
+			h.createNotification(quoted.ownerID, models.NotificationRepost, userID, &quoted.id)
+		}
+		h.redis.Delete(redis.PostCacheKey(quoted.id))
+	}
 
+	post, err := h.getPost(postID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
 		return
 	}
-	defer rows.Close()
 
-	var posts []*models.Post
-	for rows.Next() {
-		var post models.Post
-		var user models.User
-		
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Content, &post.MediaURLs, &post.MediaType,
-			&post.LikesCount, &post.CommentsCount, &post.CreatedAt, &post.UpdatedAt,
-			&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
-			&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
-			&post.IsLiked)
+	h.publishPost(post)
 
-		if err != nil {
-			continue
-		}
+	c.JSON(http.StatusCreated, post)
+}
 
-		post.User = &user
-		posts = append(posts, &post)
-	}
+// publishPost pushes a new post into followers' timelines and broadcasts it.
+func (h *Handler) publishPost(post *models.Post) {
+	h.fanOutPost(post)
 
-	// Cache the feed if it's the first page
-	if offset == 0 && len(posts) > 0 {
-		h.redis.Set(cacheKey, posts, time.Minute*5)
-	}
-
-	c.JSON(http.StatusOK, models.FeedResponse{
-		Posts:   posts,
-		HasMore: len(posts) == limit,
+	h.hub.Broadcast(map[string]interface{}{
+		"type": "new_post",
+		"data": forBroadcast(post),
 	})
 }
 
-func (h *Handler) GetPost(c *gin.Context) {
+func (h *Handler) GetFeed(c *gin.Context) {
 	userID := c.GetInt("user_id")
-	postID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+
+	switch c.Query("mode") {
+	case "", "chronological":
+	case "ranked":
+		h.getRankedFeed(c, userID)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown feed mode"})
 		return
 	}
 
-	var post models.Post
-	var user models.User
-	
-	err = h.db.QueryRow(`
-		SELECT p.id, p.user_id, p.content, p.media_urls, p.media_type, 
-		       p.likes_count, p.comments_count, p.created_at, p.updated_at,
-		       u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
-		       u.created_at, u.updated_at,
-		       EXISTS(SELECT 1 FROM likes WHERE post_id = p.id AND user_id = $2) as is_liked
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		WHERE p.id = $1`,
-		postID, userID).Scan(
-		&post.ID, &post.UserID, &post.Content, &post.MediaURLs, &post.MediaType,
-		&post.LikesCount, &post.CommentsCount, &post.CreatedAt, &post.UpdatedAt,
-		&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
-		&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
-		&post.IsLiked)
+	scope := feedScope(userID)
 
-	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
 		return
 	}
 
-	post.User = &user
-	c.JSON(http.StatusOK, post)
-}
-
-func (h *Handler) LikePost(c *gin.Context) {
-	userID := c.GetInt("user_id")
-	postID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+	// Offset paging predates timelines and still reads from the database, as
+	// do pages older than anything kept in the timeline or any page while
+	// Redis is unavailable.
+	if pg.offset > 0 {
+		h.getFeedFromDB(c, userID, scope, pg)
 		return
 	}
 
-	// Check if post exists and get owner
-	var postOwnerID int
-	err = h.db.QueryRow("SELECT user_id FROM posts WHERE id = $1", postID).Scan(&postOwnerID)
+	items, err := h.timelinePage(userID, pg)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
-			return
+		if err != errTimelineExhausted {
+			log.Printf("Failed to read timeline for user %d: %v", userID, err)
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		h.getFeedFromDB(c, userID, scope, pg)
 		return
 	}
 
-	// Insert like (will be ignored if already exists due to unique constraint)
-	_, err = h.db.Exec("INSERT INTO likes (post_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", 
-		postID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like post"})
-		return
+	hasMore := len(items) > pg.limit
+	if hasMore {
+		items = items[:pg.limit]
 	}
 
-	// Create notification if not self-like
-	if postOwnerID != userID {
-		h.createNotification(postOwnerID, models.NotificationLike, userID, &postID)
+	postIDs := make([]int, len(items))
+	for i, item := range items {
+		postIDs[i] = item.postID
 	}
 
-	// Clear cache
-	h.redis.Delete(redis.PostCacheKey(postID))
-	h.clearFollowersFeedCache(userID)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Post liked"})
-}
-
-func (h *Handler) UnlikePost(c *gin.Context) {
-	userID := c.GetInt("user_id")
-	postID, err := strconv.Atoi(c.Param("id"))
+	posts, err := h.hydratePosts(userID, postIDs)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
 		return
 	}
 
-	_, err = h.db.Exec("DELETE FROM likes WHERE post_id = $1 AND user_id = $2", postID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlike post"})
-		return
+	// The cursor follows the last timeline entry rather than the last post
+	// shown, since hidden posts are dropped during hydration.
+	response := models.FeedResponse{Posts: posts, HasMore: hasMore}
+	if len(items) > 0 {
+		last := items[len(items)-1]
+		response.NextCursor = h.nextCursor(scope, hasMore, last.createdAt, last.postID)
 	}
 
-	// Clear cache
-	h.redis.Delete(redis.PostCacheKey(postID))
-	h.clearFollowersFeedCache(userID)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Post unliked"})
+	c.JSON(http.StatusOK, response)
 }
 
-func (h *Handler) GetComments(c *gin.Context) {
-	postID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
-		return
-	}
+func (h *Handler) getFeedFromDB(c *gin.Context, userID int, scope string, pg page) {
+	afterCreatedAt, afterID := pg.afterArgs()
 
 	rows, err := h.db.Query(`
-		SELECT c.id, c.post_id, c.user_id, c.content, c.created_at, c.updated_at,
-		       u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
-		       u.created_at, u.updated_at
-		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = $1
-		ORDER BY c.created_at ASC`,
-		postID)
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$1")+`
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE (p.user_id = $1 OR p.user_id IN (
+			SELECT following_id FROM follows WHERE follower_id = $1
+		)) AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$1")+`
+		  AND ($4::timestamp IS NULL OR (p.created_at, p.id) < ($4::timestamp, $5::int))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3`,
+		userID, pg.limit+1, pg.offset, afterCreatedAt, afterID)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comments"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
 		return
 	}
 	defer rows.Close()
 
-	var comments []*models.Comment
-	for rows.Next() {
-		var comment models.Comment
-		var user models.User
-		
-		err := rows.Scan(
-			&comment.ID, &comment.PostID, &comment.UserID, &comment.Content,
-			&comment.CreatedAt, &comment.UpdatedAt,
-			&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
-			&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt)
-
-		if err != nil {
-			continue
-		}
-
-		comment.User = &user
-		comments = append(comments, &comment)
-	}
-
-	c.JSON(http.StatusOK, comments)
+	h.respondFeedPage(c, scope, h.scanFeedPosts(rows), pg.limit)
 }
 
-func (h *Handler) CreateComment(c *gin.Context) {
+func (h *Handler) GetPost(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -293,53 +202,51 @@ func (h *Handler) CreateComment(c *gin.Context) {
 		return
 	}
 
-	var req models.CreateCommentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check if post exists and get owner
-	var postOwnerID int
-	err = h.db.QueryRow("SELECT user_id FROM posts WHERE id = $1", postID).Scan(&postOwnerID)
+	post, err := h.getPost(postID, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
 		return
 	}
 
-	var comment models.Comment
-	err = h.db.QueryRow(`
-		INSERT INTO comments (post_id, user_id, content) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, post_id, user_id, content, created_at, updated_at`,
-		postID, userID, req.Content,
-	).Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Content,
-		&comment.CreatedAt, &comment.UpdatedAt)
+	c.JSON(http.StatusOK, post)
+}
 
+// LikePost adds a "like" reaction, replacing any other reaction the user
+// had on the post.
+func (h *Handler) LikePost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
 
-	// Get user info for the comment
-	user, err := h.getUserWithCounts(userID, userID)
-	if err == nil {
-		comment.User = user
+	if !h.setReaction(c, userID, postID, models.ReactionLike) {
+		return
 	}
 
-	// Create notification if not self-comment
-	if postOwnerID != userID {
-		h.createNotification(postOwnerID, models.NotificationComment, userID, &postID)
+	c.JSON(http.StatusOK, gin.H{"message": "Post liked"})
+}
+
+// UnlikePost removes the user's reaction to a post, whatever its kind, since
+// clients that only know likes show any reaction as a like.
+func (h *Handler) UnlikePost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
 	}
 
-	// Clear cache
-	h.redis.Delete(redis.PostCacheKey(postID))
+	if !h.removeReaction(c, userID, postID) {
+		return
+	}
 
-	c.JSON(http.StatusCreated, comment)
+	c.JSON(http.StatusOK, gin.H{"message": "Post unliked"})
 }
 
 func (h *Handler) GetUserPosts(c *gin.Context) {
@@ -350,32 +257,23 @@ func (h *Handler) GetUserPosts(c *gin.Context) {
 		return
 	}
 
-	limit := 20
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 50 {
-			limit = parsed
-		}
-	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+	scope := userPostsScope(userID)
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
 	}
+	afterCreatedAt, afterID := pg.afterArgs()
 
 	rows, err := h.db.Query(`
-		SELECT p.id, p.user_id, p.content, p.media_urls, p.media_type, 
-		       p.likes_count, p.comments_count, p.created_at, p.updated_at,
-		       u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
-		       u.created_at, u.updated_at,
-		       EXISTS(SELECT 1 FROM likes WHERE post_id = p.id AND user_id = $2) as is_liked
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$2")+`
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		WHERE p.user_id = $1
-		ORDER BY p.created_at DESC
+		WHERE p.user_id = $1 AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2")+`
+		  AND ($5::timestamp IS NULL OR (p.created_at, p.id) < ($5::timestamp, $6::int))
+		ORDER BY p.created_at DESC, p.id DESC
 		LIMIT $3 OFFSET $4`,
-		userID, currentUserID, limit, offset)
+		userID, currentUserID, pg.limit+1, pg.offset, afterCreatedAt, afterID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user posts"})
@@ -383,53 +281,123 @@ func (h *Handler) GetUserPosts(c *gin.Context) {
 	}
 	defer rows.Close()
 
-	var posts []*models.Post
-	for rows.Next() {
-		var post models.Post
-		var user models.User
-		
-		err := rows.Scan(
-			&post.ID, &post.UserID, &post.Content, &post.MediaURLs, &post.MediaType,
-			&post.LikesCount, &post.CommentsCount, &post.CreatedAt, &post.UpdatedAt,
-			&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
-			&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
-			&post.IsLiked)
+	h.respondFeedPage(c, scope, h.scanFeedPosts(rows), pg.limit)
+}
 
-		if err != nil {
-			continue
-		}
+// getPost loads a single post as seen by viewerID. A post by a suspended
+// author or by someone the viewer has blocked or been blocked by, or a
+// repost of a post that is no longer visible, is reported as not found.
+func (h *Handler) getPost(postID, viewerID int) (*models.Post, error) {
+	post, err := scanPost(h.db.QueryRow(`
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$2")+`
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = $1 AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2"),
+		postID, viewerID))
+	if err != nil {
+		return nil, err
+	}
 
-		post.User = &user
-		posts = append(posts, &post)
+	posts, err := h.attachEmbeds(viewerID, []*models.Post{post})
+	if err != nil {
+		return nil, err
 	}
+	if len(posts) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return posts[0], nil
+}
 
-	c.JSON(http.StatusOK, models.FeedResponse{
-		Posts:   posts,
-		HasMore: len(posts) == limit,
-	})
+// postColumns selects a post and its author from posts p joined to users u,
+// in the order scanPost reads them. Queries follow it with the viewer's
+// columns from viewerSQL.
+const postColumns = `p.id, p.user_id, p.content, p.media_urls, p.media_type, p.mentions,
+		       p.likes_count, p.reaction_counts, p.comments_count, p.reposts_count, p.repost_of_id, p.quote_of_id,
+		       p.created_at, p.updated_at, p.edited_at,
+		       u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.created_at, u.updated_at`
+
+// viewerSQL is the columns scanPost reads after postColumns: the reaction
+// and bookmark of the user ID in the placeholder param (e.g. "$2").
+func viewerSQL(param string) string {
+	return reactionSQL(param) + `,
+		       EXISTS(SELECT 1 FROM bookmarks WHERE post_id = p.id AND user_id = ` + param + `) AS is_bookmarked`
 }
 
-// Helper functions
-func (h *Handler) clearFollowersFeedCache(userID int) {
-	// Get followers and clear their feed cache
-	rows, err := h.db.Query("SELECT follower_id FROM follows WHERE following_id = $1", userID)
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPost reads postColumns followed by viewerSQL.
+func scanPost(row scanner) (*models.Post, error) {
+	var post models.Post
+	var user models.User
+
+	err := row.Scan(
+		&post.ID, &post.UserID, &post.Content, &post.MediaURLs, &post.MediaType, &post.Mentions,
+		&post.LikesCount, &post.ReactionCounts, &post.CommentsCount, &post.RepostsCount, &post.RepostOfID, &post.QuoteOfID,
+		&post.CreatedAt, &post.UpdatedAt, &post.EditedAt,
+		&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+		&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+		&post.Reaction, &post.IsBookmarked)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer rows.Close()
 
+	post.IsLiked = post.Reaction != ""
+	post.Edited = post.EditedAt != nil
+	post.User = &user
+	return &post, nil
+}
+
+// scanFeedPosts reads rows of postColumns and viewerSQL, skipping any that
+// fail to scan.
+func (h *Handler) scanFeedPosts(rows *sql.Rows) []*models.Post {
+	posts := []*models.Post{}
 	for rows.Next() {
-		var followerID int
-		if rows.Scan(&followerID) == nil {
-			h.redis.Delete(redis.FeedCacheKey(followerID))
+		post, err := scanPost(rows)
+		if err != nil {
+			continue
 		}
+		posts = append(posts, post)
 	}
-	
-	// Also clear own feed cache
-	h.redis.Delete(redis.FeedCacheKey(userID))
+	return posts
+}
+
+// respondFeedPage trims the lookahead row fetched past limit and writes the
+// page with a cursor for the next one.
+func (h *Handler) respondFeedPage(c *gin.Context, scope string, posts []*models.Post, limit int) {
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+
+	response := models.FeedResponse{HasMore: hasMore}
+	if len(posts) > 0 {
+		last := posts[len(posts)-1]
+		response.NextCursor = h.nextCursor(scope, hasMore, last.CreatedAt, last.ID)
+	}
+
+	// The cursor is taken first since reposts of hidden posts are dropped.
+	posts, err := h.attachEmbeds(c.GetInt("user_id"), posts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
+		return
+	}
+	response.Posts = posts
+
+	c.JSON(http.StatusOK, response)
 }
 
+// Helper functions
+// createNotification notifies userID of actorID's action, unless either has
+// blocked the other.
 func (h *Handler) createNotification(userID int, notifType models.NotificationType, actorID int, postID *int) {
+	if blocked, err := h.isBlocked(userID, actorID); err != nil || blocked {
+		return
+	}
+
 	_, err := h.db.Exec(`
 		INSERT INTO notifications (user_id, type, actor_id, post_id) 
 		VALUES ($1, $2, $3, $4)`,
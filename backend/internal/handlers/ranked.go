@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/ranking"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	rankedCandidateWindow = 72 * time.Hour
+	rankedCandidateLimit  = 500
+	rankedInteractionDays = 30
+	// rankedSnapshotTTL is how long a ranked ordering is kept for paging
+	// before the viewer has to start again from the top.
+	rankedSnapshotTTL = 30 * time.Minute
+)
+
+// rankedSnapshot is the ordering a viewer pages through. Scores drift as
+// posts age and gain likes, so pages are cut from a fixed snapshot instead of
+// re-ranking on every request.
+type rankedSnapshot struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Entries     []rankedEntry `json:"entries"`
+}
+
+type rankedEntry struct {
+	PostID      int                        `json:"post_id"`
+	Explanation *models.RankingExplanation `json:"explanation"`
+}
+
+// getRankedFeed serves GET /posts/feed?mode=ranked. Its cursor reuses the
+// keyset format with the snapshot's generation time and the index of the
+// next entry.
+func (h *Handler) getRankedFeed(c *gin.Context, userID int) {
+	scope := fmt.Sprintf("ranked_feed:%d", userID)
+	pg, ok := h.parsePage(c, scope)
+	if !ok {
+		return
+	}
+
+	key := redis.RankedFeedKey(userID)
+	var snapshot rankedSnapshot
+	start := pg.offset
+
+	if pg.after != nil {
+		err := h.redis.Get(key, &snapshot)
+		if err != nil || !snapshot.GeneratedAt.Equal(pg.after.CreatedAt) {
+			c.JSON(http.StatusGone, gin.H{"error": "Ranked feed has been refreshed, reload from the start"})
+			return
+		}
+		start = pg.after.ID
+	} else if pg.offset == 0 || h.redis.Get(key, &snapshot) != nil {
+		built, err := h.buildRankedSnapshot(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+			return
+		}
+		snapshot = *built
+	}
+
+	h.redis.Set(key, snapshot, rankedSnapshotTTL)
+
+	if start > len(snapshot.Entries) {
+		start = len(snapshot.Entries)
+	}
+	end := start + pg.limit
+	if end > len(snapshot.Entries) {
+		end = len(snapshot.Entries)
+	}
+	entries := snapshot.Entries[start:end]
+
+	postIDs := make([]int, len(entries))
+	explanations := make(map[int]*models.RankingExplanation, len(entries))
+	for i, entry := range entries {
+		postIDs[i] = entry.PostID
+		explanations[entry.PostID] = entry.Explanation
+	}
+
+	posts, err := h.hydratePosts(userID, postIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get feed"})
+		return
+	}
+	for _, post := range posts {
+		post.Ranking = explanations[post.ID]
+	}
+
+	hasMore := end < len(snapshot.Entries)
+	response := models.FeedResponse{Posts: posts, HasMore: hasMore}
+	if hasMore {
+		response.NextCursor = h.cursors.Encode(scope, cursor.Position{CreatedAt: snapshot.GeneratedAt, ID: end})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// buildRankedSnapshot scores recent posts from followed accounts and from
+// accounts they follow.
+func (h *Handler) buildRankedSnapshot(userID int) (*rankedSnapshot, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	rows, err := h.db.Query(`
+		WITH followed AS (
+			SELECT following_id AS id FROM follows WHERE follower_id = $1
+		),
+		second_degree AS (
+			SELECT f.following_id AS id, COUNT(*) AS via
+			FROM follows f
+			JOIN followed ON f.follower_id = followed.id
+			WHERE f.following_id <> $1 AND f.following_id NOT IN (SELECT id FROM followed)
+			GROUP BY f.following_id
+		),
+		authors AS (
+			SELECT id, 0 AS via FROM followed
+			UNION ALL
+			SELECT id, via FROM second_degree
+		)
+		SELECT p.id, p.user_id, p.created_at, p.likes_count, p.comments_count, a.via
+		FROM posts p
+		JOIN authors a ON p.user_id = a.id
+		JOIN users u ON p.user_id = u.id
+		WHERE p.created_at > $2 AND p.deleted_at IS NULL AND p.repost_of_id IS NULL
+		  AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$1")+`
+		ORDER BY p.created_at DESC
+		LIMIT $3`,
+		userID, now.Add(-rankedCandidateWindow), rankedCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []ranking.Candidate
+	for rows.Next() {
+		var candidate ranking.Candidate
+		err := rows.Scan(&candidate.PostID, &candidate.AuthorID, &candidate.CreatedAt,
+			&candidate.LikesCount, &candidate.CommentsCount, &candidate.Via)
+		if err != nil {
+			continue
+		}
+
+		if candidate.Via > 0 {
+			candidate.Source = ranking.SourceSecondDegree
+		}
+		candidates = append(candidates, candidate)
+	}
+	rows.Close()
+
+	interactions, err := h.authorInteractions(userID, now.AddDate(0, 0, -rankedInteractionDays))
+	if err != nil {
+		return nil, err
+	}
+
+	scorer := h.rankers.ForUser(userID)
+	snapshot := &rankedSnapshot{GeneratedAt: now, Entries: make([]rankedEntry, 0, len(candidates))}
+	for _, candidate := range candidates {
+		candidate.Interactions = interactions[candidate.AuthorID]
+		snapshot.Entries = append(snapshot.Entries, rankedEntry{
+			PostID:      candidate.PostID,
+			Explanation: scorer.Score(candidate, now),
+		})
+	}
+
+	sort.SliceStable(snapshot.Entries, func(i, j int) bool {
+		return snapshot.Entries[i].Explanation.Score > snapshot.Entries[j].Explanation.Score
+	})
+
+	return snapshot, nil
+}
+
+// authorInteractions counts userID's likes and comments on each author's
+// posts since the given time.
+func (h *Handler) authorInteractions(userID int, since time.Time) (map[int]int, error) {
+	rows, err := h.db.Query(`
+		SELECT p.user_id, COUNT(*)
+		FROM (
+			SELECT post_id FROM likes WHERE user_id = $1 AND created_at > $2
+			UNION ALL
+			SELECT post_id FROM comments WHERE user_id = $1 AND created_at > $2
+		) i
+		JOIN posts p ON p.id = i.post_id
+		WHERE p.user_id <> $1
+		GROUP BY p.user_id`,
+		userID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interactions := make(map[int]int)
+	for rows.Next() {
+		var authorID, count int
+		if rows.Scan(&authorID, &count) == nil {
+			interactions[authorID] = count
+		}
+	}
+	return interactions, nil
+}
@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reactions live in the likes table, one per user per post, with the kind
+// alongside. The likes_count trigger keeps likes_count as the total and
+// reaction_counts per kind.
+
+// ReactToPost sets the user's reaction to a post, replacing any reaction of
+// another kind.
+func (h *Handler) ReactToPost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req models.ReactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.reactionAllowed(req.Kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown reaction", "kinds": h.reactionKinds()})
+		return
+	}
+
+	if !h.setReaction(c, userID, postID, req.Kind) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction saved", "reaction": req.Kind})
+}
+
+// RemoveReaction clears the user's reaction to a post.
+func (h *Handler) RemoveReaction(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	if !h.removeReaction(c, userID, postID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction removed"})
+}
+
+// setReaction saves userID's reaction to postID, writing an error response
+// and returning false if it can't. The post's owner is notified the first
+// time a user reacts, but not when they change kind.
+func (h *Handler) setReaction(c *gin.Context, userID, postID int, kind string) bool {
+	// Check if post exists and get owner
+	var postOwnerID int
+	err := h.db.QueryRow("SELECT user_id FROM posts WHERE id = $1 AND deleted_at IS NULL", postID).Scan(&postOwnerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	if h.rejectBlocked(c, userID, postOwnerID) {
+		return false
+	}
+
+	// xmax is 0 only on a newly inserted row. No row comes back when the
+	// user already had this reaction.
+	var inserted bool
+	err = h.db.QueryRow(`
+		INSERT INTO likes (post_id, user_id, kind)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id) DO UPDATE SET kind = EXCLUDED.kind
+		WHERE likes.kind <> EXCLUDED.kind
+		RETURNING xmax = 0`,
+		postID, userID, kind).Scan(&inserted)
+	if err == sql.ErrNoRows {
+		return true
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to react to post"})
+		return false
+	}
+
+	// Create notification if not self-reaction
+	if inserted && postOwnerID != userID {
+		notificationType := models.NotificationReaction
+		if kind == models.ReactionLike {
+			notificationType = models.NotificationLike
+		}
+		h.createNotification(postOwnerID, notificationType, userID, &postID)
+	}
+
+	// Clear cache
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	return true
+}
+
+// removeReaction deletes userID's reaction to postID, writing an error
+// response and returning false if it can't.
+func (h *Handler) removeReaction(c *gin.Context, userID, postID int) bool {
+	_, err := h.db.Exec("DELETE FROM likes WHERE post_id = $1 AND user_id = $2", postID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
+		return false
+	}
+
+	// Clear cache
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	return true
+}
+
+// reactionKinds lists the kinds configured in cfg.ReactionKinds, which
+// always include "like".
+func (h *Handler) reactionKinds() []string {
+	kinds := []string{models.ReactionLike}
+	for _, kind := range strings.Split(h.cfg.ReactionKinds, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind != "" && kind != models.ReactionLike {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func (h *Handler) reactionAllowed(kind string) bool {
+	for _, allowed := range h.reactionKinds() {
+		if kind == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// reactionSQL is a column with the reaction to post p by the user ID in the
+// placeholder param (e.g. "$2"), or an empty string if they haven't reacted.
+func reactionSQL(param string) string {
+	return `COALESCE((SELECT kind FROM likes WHERE post_id = p.id AND user_id = ` + param + `), '') AS reaction`
+}
+
+// GetPostLikes lists the users who reacted to a post, or only those who
+// reacted with ?kind=. Users the viewer follows come first; each group is
+// ordered by when they reacted, newest first. As with comments, the next
+// cursor travels in the X-Next-Cursor header.
+func (h *Handler) GetPostLikes(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	kind := c.Query("kind")
+	if kind != "" && !h.reactionAllowed(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown reaction", "kinds": h.reactionKinds()})
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)", postID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	// The cursor's scope says which group it stopped in.
+	followed := true
+	var afterCreatedAt, afterID interface{}
+	if token := c.Query("cursor"); token != "" {
+		after, err := h.cursors.Decode(postLikesScope(postID, userID, kind, true), token)
+		if err != nil {
+			followed = false
+			after, err = h.cursors.Decode(postLikesScope(postID, userID, kind, false), token)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		afterCreatedAt, afterID = after.CreatedAt, after.ID
+	}
+
+	limit := pageLimit(c)
+
+	// Each group is read with its own query so both can walk the likes index
+	// in order; the second only runs if the first doesn't fill the page.
+	var likers []postLiker
+	if followed {
+		likers, err = h.postLikers(postID, userID, kind, true, afterCreatedAt, afterID, limit+1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get likes"})
+			return
+		}
+		if len(likers) > limit {
+			likers = likers[:limit]
+			c.Header("X-Next-Cursor", h.cursors.Encode(postLikesScope(postID, userID, kind, true), likers[limit-1].likedAt))
+			c.JSON(http.StatusOK, likerUsers(likers))
+			return
+		}
+		afterCreatedAt, afterID = nil, nil
+	}
+
+	remaining := limit - len(likers)
+	others, err := h.postLikers(postID, userID, kind, false, afterCreatedAt, afterID, remaining+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get likes"})
+		return
+	}
+	if len(others) > remaining {
+		others = others[:remaining]
+		// A page that ends exactly at the last followed user continues from
+		// there, which leads straight into the second group.
+		if remaining == 0 {
+			c.Header("X-Next-Cursor", h.cursors.Encode(postLikesScope(postID, userID, kind, true), likers[len(likers)-1].likedAt))
+		} else {
+			c.Header("X-Next-Cursor", h.cursors.Encode(postLikesScope(postID, userID, kind, false), others[remaining-1].likedAt))
+		}
+	}
+
+	c.JSON(http.StatusOK, likerUsers(append(likers, others...)))
+}
+
+// postLiker is a user who reacted to a post and when.
+type postLiker struct {
+	user    *models.User
+	likedAt cursor.Position
+}
+
+func likerUsers(likers []postLiker) []*models.User {
+	users := make([]*models.User, len(likers))
+	for i, liker := range likers {
+		users[i] = liker.user
+	}
+	return users
+}
+
+// postLikers reads up to limit users who reacted to postID after the given
+// position, newest first, from among those viewerID follows or, if followed
+// is false, everyone else.
+func (h *Handler) postLikers(postID, viewerID int, kind string, followed bool, afterCreatedAt, afterID interface{}, limit int) ([]postLiker, error) {
+	group := `JOIN follows f ON f.follower_id = $2 AND f.following_id = l.user_id`
+	filter := `TRUE`
+	if !followed {
+		group = ``
+		filter = `NOT EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = l.user_id)`
+	}
+
+	rows, err := h.db.Query(`
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.created_at, u.updated_at, l.created_at, l.id
+		FROM likes l
+		JOIN users u ON l.user_id = u.id
+		`+group+`
+		WHERE l.post_id = $1 AND ($3 = '' OR l.kind = $3) AND `+filter+`
+		  AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2")+`
+		  AND ($4::timestamp IS NULL OR (l.created_at, l.id) < ($4::timestamp, $5::int))
+		ORDER BY l.created_at DESC, l.id DESC
+		LIMIT $6`,
+		postID, viewerID, kind, afterCreatedAt, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	likers := []postLiker{}
+	for rows.Next() {
+		var user models.User
+		var likedAt cursor.Position
+		err := rows.Scan(
+			&user.ID, &user.Username, &user.Email, &user.FullName, &user.Bio,
+			&user.Avatar, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+			&likedAt.CreatedAt, &likedAt.ID)
+		if err != nil {
+			continue
+		}
+		user.IsFollowing = followed
+		likers = append(likers, postLiker{user: &user, likedAt: likedAt})
+	}
+	return likers, nil
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// sharedPost is the original post behind a repost or quote.
+type sharedPost struct {
+	id      int
+	ownerID int
+}
+
+// loadSharedPost finds the post userID wants to repost or quote, following
+// a repost through to the post it shares. It writes an error response and
+// returns false if the post can't be shared.
+func (h *Handler) loadSharedPost(c *gin.Context, userID, postID int) (*sharedPost, bool) {
+	var shared sharedPost
+	err := h.db.QueryRow(`
+		SELECT p.id, p.user_id
+		FROM posts r
+		JOIN posts p ON p.id = COALESCE(r.repost_of_id, r.id)
+		JOIN users u ON p.user_id = u.id
+		WHERE r.id = $1 AND r.deleted_at IS NULL AND p.deleted_at IS NULL AND `+notSuspendedSQL,
+		postID).Scan(&shared.id, &shared.ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return nil, false
+	}
+
+	if blocked, err := h.isBlocked(userID, shared.ownerID); err != nil || blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot share this post"})
+		return nil, false
+	}
+
+	return &shared, true
+}
+
+// RepostPost shares a post to the reposter's followers as-is. Quotes are
+// created through CreatePost with quote_of_id.
+func (h *Handler) RepostPost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	original, ok := h.loadSharedPost(c, userID, postID)
+	if !ok {
+		return
+	}
+
+	var repostID int
+	err = h.db.QueryRow(`
+		INSERT INTO posts (user_id, content, repost_of_id)
+		VALUES ($1, '', $2)
+		ON CONFLICT (user_id, repost_of_id) WHERE repost_of_id IS NOT NULL AND deleted_at IS NULL
+		DO NOTHING
+		RETURNING id`,
+		userID, original.id).Scan(&repostID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"error": "Post already reposted"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repost"})
+		return
+	}
+
+	// Create notification if not self-repost
+	if original.ownerID != userID {
+		h.createNotification(original.ownerID, models.NotificationRepost, userID, &original.id)
+	}
+
+	// Clear cache
+	h.redis.Delete(redis.PostCacheKey(original.id))
+
+	repost, err := h.getPost(repostID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
+		return
+	}
+
+	h.publishPost(repost)
+
+	c.JSON(http.StatusCreated, repost)
+}
+
+// UnrepostPost removes the viewer's repost of a post. Reposts have nothing
+// of their own worth keeping, so they are deleted outright.
+func (h *Handler) UnrepostPost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var repostID int
+	err = h.db.QueryRow(`
+		DELETE FROM posts
+		WHERE user_id = $1 AND repost_of_id = $2 AND deleted_at IS NULL
+		RETURNING id`,
+		userID, postID).Scan(&repostID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Repost not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove repost"})
+		return
+	}
+
+	h.redis.Delete(redis.PostCacheKey(postID))
+	h.postRemoved(repostID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Repost removed"})
+}
+
+// attachEmbeds fills in RepostOf and QuotedPost as seen by viewerID.
+// Reposts of posts that are no longer visible, or whose author the viewer
+// has blocked or been blocked by, are dropped; quotes of them get a
+// tombstone.
+func (h *Handler) attachEmbeds(viewerID int, posts []*models.Post) ([]*models.Post, error) {
+	ids := []int64{}
+	for _, post := range posts {
+		if post.RepostOfID != nil {
+			ids = append(ids, int64(*post.RepostOfID))
+		}
+		if post.QuoteOfID != nil {
+			ids = append(ids, int64(*post.QuoteOfID))
+		}
+	}
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	rows, err := h.db.Query(`
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$2")+`
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($1) AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2"),
+		pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	originals := make(map[int]*models.Post, len(ids))
+	for _, post := range h.scanFeedPosts(rows) {
+		originals[post.ID] = post
+	}
+	rows.Close()
+
+	visible := make([]*models.Post, 0, len(posts))
+	for _, post := range posts {
+		post.RepostOf, post.QuotedPost = nil, nil
+		if post.RepostOfID != nil {
+			original, ok := originals[*post.RepostOfID]
+			if !ok {
+				continue
+			}
+			post.RepostOf = original
+		}
+		if post.QuoteOfID != nil {
+			if original, ok := originals[*post.QuoteOfID]; ok {
+				post.QuotedPost = original
+			} else {
+				post.QuotedPost = removedPost(*post.QuoteOfID)
+			}
+		}
+		visible = append(visible, post)
+	}
+	return visible, nil
+}
+
+// forBroadcast copies post for sending to every client, without the
+// requesting viewer's reactions and bookmarks.
+func forBroadcast(post *models.Post) models.Post {
+	broadcast := *post
+	broadcast.Reaction, broadcast.IsLiked, broadcast.IsBookmarked = "", false, false
+	for _, embed := range []**models.Post{&broadcast.RepostOf, &broadcast.QuotedPost} {
+		if *embed != nil {
+			copied := **embed
+			copied.Reaction, copied.IsLiked, copied.IsBookmarked = "", false, false
+			*embed = &copied
+		}
+	}
+	return broadcast
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdatePost lets the author edit a post within the configured edit window.
+// The version being replaced is kept in post_revisions.
+func (h *Handler) UpdatePost(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req models.UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var ownerID int
+	var content, mediaType string
+	var mediaURLs models.MediaURLs
+	var mentions models.Mentions
+	var repostOfID *int
+	var createdAt time.Time
+	err = tx.QueryRow(`
+		SELECT user_id, content, media_urls, media_type, mentions, repost_of_id, created_at
+		FROM posts WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE`,
+		postID).Scan(&ownerID, &content, &mediaURLs, &mediaType, &mentions, &repostOfID, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if ownerID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own posts"})
+		return
+	}
+
+	if repostOfID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reposts can't be edited"})
+		return
+	}
+
+	if window := time.Duration(h.cfg.PostEditWindowMinutes) * time.Minute; window > 0 && time.Since(createdAt) > window {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This post can no longer be edited"})
+		return
+	}
+
+	newMediaURLs, newMediaType := mediaURLs, mediaType
+	if req.MediaURLs != nil {
+		if !h.checkPostMedia(c, userID, *req.MediaURLs, mediaURLs) {
+			return
+		}
+		newMediaURLs = models.MediaURLs(*req.MediaURLs)
+		newMediaType = ""
+		if req.MediaType != nil {
+			newMediaType = *req.MediaType
+		}
+	}
+
+	if req.Content == content && newMediaType == mediaType && equalMediaURLs(newMediaURLs, mediaURLs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to change"})
+		return
+	}
+
+	newMentions, err := h.resolveMentions(userID, req.Content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO post_revisions (post_id, content, media_urls, media_type)
+		VALUES ($1, $2, $3, $4)`,
+		postID, content, mediaURLs, mediaType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE posts SET content = $1, media_urls = $2, media_type = $3, mentions = $4,
+		       edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5`,
+		req.Content, newMediaURLs, newMediaType, newMentions, postID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	if err := indexHashtags(tx, postID, req.Content); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	h.recordHashtagUses(addedHashtags(content, req.Content))
+	h.notifyMentions(newMentions, userID, postID, mentionedUsers(mentions))
+
+	// Timelines and ranked feeds only hold post IDs and read bodies from the
+	// post cache, so this is the only cached copy to drop.
+	h.redis.Delete(redis.PostCacheKey(postID))
+
+	post, err := h.getPost(postID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
+		return
+	}
+
+	h.hub.Broadcast(map[string]interface{}{
+		"type": "post_updated",
+		"data": forBroadcast(post),
+	})
+
+	c.JSON(http.StatusOK, post)
+}
+
+func (h *Handler) GetPostRevisions(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var exists bool
+	err = h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)", postID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, post_id, content, media_urls, media_type, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY created_at DESC, id DESC`,
+		postID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get revisions"})
+		return
+	}
+	defer rows.Close()
+
+	revisions := []*models.PostRevision{}
+	for rows.Next() {
+		var revision models.PostRevision
+		err := rows.Scan(&revision.ID, &revision.PostID, &revision.Content, &revision.MediaURLs,
+			&revision.MediaType, &revision.CreatedAt)
+		if err != nil {
+			continue
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+func equalMediaURLs(a, b models.MediaURLs) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
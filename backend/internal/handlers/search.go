@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/entities"
+	"pulsefeed-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// ts_headline marks matches with these private-use characters so the
+// snippet can be HTML-escaped before they're swapped for <mark> tags.
+const (
+	snippetStart = "\uE000"
+	snippetStop  = "\uE001"
+)
+
+var snippetOptions = "StartSel=" + snippetStart + ", StopSel=" + snippetStop +
+	`, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>")
+
+// postSearch is a parsed search query. Words and "quoted phrases" are
+// matched against post content with websearch_to_tsquery, which also takes
+// OR and -word. from:username, #tag, since:YYYY-MM-DD and until:YYYY-MM-DD
+// (both inclusive) narrow the results.
+type postSearch struct {
+	text  string
+	from  string
+	tags  []string
+	since *time.Time
+	until *time.Time
+}
+
+func parsePostSearch(q string) (postSearch, bool) {
+	var search postSearch
+	var words []string
+	for _, field := range strings.Fields(q) {
+		lower := strings.ToLower(field)
+		switch {
+		case strings.HasPrefix(lower, "from:") && len(lower) > len("from:"):
+			search.from = strings.TrimPrefix(strings.TrimPrefix(lower, "from:"), "@")
+		case strings.HasPrefix(lower, "since:"), strings.HasPrefix(lower, "until:"):
+			day, err := time.Parse("2006-01-02", lower[len("since:"):])
+			if err != nil {
+				return postSearch{}, false
+			}
+			if strings.HasPrefix(lower, "since:") {
+				search.since = &day
+			} else {
+				end := day.AddDate(0, 0, 1)
+				search.until = &end
+			}
+		case strings.HasPrefix(field, "#"):
+			if tag, ok := entities.NormalizeHashtag(field); ok {
+				search.tags = append(search.tags, tag)
+				continue
+			}
+			words = append(words, field)
+		default:
+			words = append(words, field)
+		}
+	}
+	search.text = strings.Join(words, " ")
+	return search, true
+}
+
+// SearchPosts serves GET /posts/search?q=. Results are ordered by relevance
+// when q has words to match, and newest first otherwise or with
+// sort=recent.
+func (h *Handler) SearchPosts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	search, ok := parsePostSearch(q)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be YYYY-MM-DD"})
+		return
+	}
+	if search.text == "" && search.from == "" && len(search.tags) == 0 && search.since == nil && search.until == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	sort := "relevance"
+	if search.text == "" || c.Query("sort") == "recent" {
+		sort = "recent"
+	}
+	scope := postSearchScope(userID, sort, q)
+
+	var pg page
+	var afterScore *cursor.ScorePosition
+	if sort == "relevance" {
+		pg, afterScore, ok = h.parseScorePage(c, scope)
+	} else {
+		pg, ok = h.parsePage(c, scope)
+	}
+	if !ok {
+		return
+	}
+
+	var order string
+	var afterA, afterB interface{}
+	if sort == "relevance" {
+		order = `AND ($9::real IS NULL OR (ts_rank(p.search_vector, q.query), p.id) < ($9::real, $10::int))
+		ORDER BY rank DESC, p.id DESC`
+		if afterScore != nil {
+			afterA, afterB = afterScore.Score, afterScore.ID
+		}
+	} else {
+		order = `AND ($9::timestamp IS NULL OR (p.created_at, p.id) < ($9::timestamp, $10::int))
+		ORDER BY p.created_at DESC, p.id DESC`
+		afterA, afterB = pg.afterArgs()
+	}
+
+	rows, err := h.db.Query(`
+		WITH q AS (SELECT websearch_to_tsquery('english', $2) AS query)
+		SELECT `+postColumns+`,
+		       `+viewerSQL("$1")+`,
+		       ts_rank(p.search_vector, q.query) AS rank,
+		       CASE WHEN $2 = '' THEN '' ELSE ts_headline('english', p.content, q.query, $11) END AS snippet
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		CROSS JOIN q
+		WHERE p.deleted_at IS NULL AND p.repost_of_id IS NULL
+		  AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$1")+`
+		  AND ($2 = '' OR p.search_vector @@ q.query)
+		  AND ($3 = '' OR LOWER(u.username) = $3)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM unnest($4::text[]) AS wanted(tag)
+		      WHERE NOT EXISTS (
+		          SELECT 1 FROM post_hashtags ph
+		          JOIN hashtags t ON t.id = ph.hashtag_id
+		          WHERE ph.post_id = p.id AND t.tag = wanted.tag))
+		  AND ($5::timestamp IS NULL OR p.created_at >= $5)
+		  AND ($6::timestamp IS NULL OR p.created_at < $6)
+		  `+order+`
+		LIMIT $7 OFFSET $8`,
+		userID, search.text, search.from, pq.Array(search.tags), search.since, search.until,
+		pg.limit+1, pg.offset, afterA, afterB, snippetOptions)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+	defer rows.Close()
+
+	posts := []*models.Post{}
+	ranks := make(map[int]float64)
+	for rows.Next() {
+		var rank float64
+		var snippet string
+		post, err := scanPost(withExtra(rows, &rank, &snippet))
+		if err != nil {
+			continue
+		}
+
+		if snippet != "" {
+			post.Snippet = snippetMarks.Replace(html.EscapeString(snippet))
+		}
+		ranks[post.ID] = rank
+		posts = append(posts, post)
+	}
+
+	if sort == "recent" {
+		h.respondFeedPage(c, scope, posts, pg.limit)
+		return
+	}
+
+	hasMore := len(posts) > pg.limit
+	if hasMore {
+		posts = posts[:pg.limit]
+	}
+
+	response := models.FeedResponse{HasMore: hasMore}
+	if hasMore {
+		last := posts[len(posts)-1]
+		response.NextCursor = h.cursors.EncodeScore(scope, cursor.ScorePosition{Score: ranks[last.ID], ID: last.ID})
+	}
+
+	posts, err = h.attachEmbeds(userID, posts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts"})
+		return
+	}
+	response.Posts = posts
+
+	c.JSON(http.StatusOK, response)
+}
+
+// extraScanner scans a row's trailing columns into extra after the columns
+// the wrapped caller asks for.
+type extraScanner struct {
+	row   scanner
+	extra []interface{}
+}
+
+func withExtra(row scanner, extra ...interface{}) scanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/middleware"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	sessionCacheTTL    = time.Minute
+	sessionSeenEvery   = time.Minute
+	maxDeviceNameRunes = 100
+)
+
+var errSessionRevoked = errors.New("session revoked")
+
+type queryer interface {
+	execer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	currentSessionID := c.GetString("session_id")
+
+	rows, err := h.db.Query(`
+		SELECT id, device_name, user_agent, ip_address, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC`,
+		userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+		return
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(&session.ID, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastSeenAt)
+		if err != nil {
+			continue
+		}
+
+		session.IsCurrent = session.ID == currentSessionID
+		sessions = append(sessions, &session)
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	sessionID := c.Param("id")
+
+	if _, err := uuid.Parse(sessionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	revoked, err := h.revokeSession(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeAllSessions signs the user out everywhere. With ?except_current=true
+// the session making the request stays signed in.
+func (h *Handler) RevokeAllSessions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	keepSessionID := ""
+	if c.Query("except_current") == "true" {
+		keepSessionID = c.GetString("session_id")
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	revoked, err := revokeSessions(tx, userID, "", keepSessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	h.afterSessionsRevoked(revoked)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sessions revoked", "revoked": len(revoked)})
+}
+
+// ValidateSession implements middleware.SessionValidator. Active sessions
+// of users in good standing are cached briefly so the common path doesn't
+// hit the database; suspending a user drops those entries.
+func (h *Handler) ValidateSession(userID int, sessionID string) error {
+	if sessionID == "" {
+		return errSessionRevoked
+	}
+
+	var cachedUserID int
+	if h.redis.Get(redis.SessionKey(sessionID), &cachedUserID) != nil || cachedUserID != userID {
+		var ownerID int
+		var revokedAt sql.NullTime
+		var suspended bool
+		err := h.db.QueryRow(`
+			SELECT s.user_id, s.revoked_at, NOT `+notSuspendedSQL+`
+			FROM sessions s
+			JOIN users u ON s.user_id = u.id
+			WHERE s.id = $1`,
+			sessionID).Scan(&ownerID, &revokedAt, &suspended)
+		if err != nil || ownerID != userID || revokedAt.Valid {
+			return errSessionRevoked
+		}
+		if suspended {
+			return middleware.ErrAccountSuspended
+		}
+
+		h.redis.Set(redis.SessionKey(sessionID), userID, sessionCacheTTL)
+	}
+
+	if first, _ := h.redis.SetNX(redis.SessionSeenKey(sessionID), true, sessionSeenEvery); first {
+		h.db.Exec("UPDATE sessions SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1", sessionID)
+	}
+
+	return nil
+}
+
+func createSession(db execer, c *gin.Context, userID int) (string, error) {
+	sessionID := uuid.New().String()
+	if err := insertSession(db, sessionID, c, userID); err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// insertSession records the requesting device. Clients name themselves with
+// the X-Device-Name header.
+func insertSession(db execer, sessionID string, c *gin.Context, userID int) error {
+	deviceName := []rune(strings.TrimSpace(c.GetHeader("X-Device-Name")))
+	if len(deviceName) > maxDeviceNameRunes {
+		deviceName = deviceName[:maxDeviceNameRunes]
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO sessions (id, user_id, device_name, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING`,
+		sessionID, userID, string(deviceName), c.Request.UserAgent(), c.ClientIP())
+	return err
+}
+
+func (h *Handler) revokeSession(userID int, sessionID string) (bool, error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	revoked, err := revokeSessions(tx, userID, sessionID, "")
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	h.afterSessionsRevoked(revoked)
+
+	return len(revoked) > 0, nil
+}
+
+// revokeSessions revokes the user's sessions and their refresh tokens. An
+// empty onlySessionID means every session except keepSessionID (if set). It
+// returns the newly revoked IDs for afterSessionsRevoked once committed.
+func revokeSessions(db queryer, userID int, onlySessionID, keepSessionID string) ([]string, error) {
+	rows, err := db.Query(`
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL
+		  AND ($2 = '' OR id::text = $2) AND id::text <> $3
+		RETURNING id`,
+		userID, onlySessionID, keepSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		revoked = append(revoked, id)
+	}
+	rows.Close()
+
+	_, err = db.Exec(`
+		UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL
+		  AND ($2 = '' OR family_id::text = $2) AND family_id::text <> $3`,
+		userID, onlySessionID, keepSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return revoked, nil
+}
+
+// afterSessionsRevoked drops cached session state and closes live
+// WebSocket connections for the revoked sessions.
+func (h *Handler) afterSessionsRevoked(sessionIDs []string) {
+	for _, id := range sessionIDs {
+		h.redis.Delete(redis.SessionKey(id))
+	}
+	h.hub.DisconnectSessions(sessionIDs...)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notSuspendedSQL matches rows of users aliased u who are in good standing.
+// A suspension with no end date is permanent.
+const notSuspendedSQL = `(u.suspended_at IS NULL OR u.suspended_until <= CURRENT_TIMESTAMP)`
+
+// rejectSuspended writes a 403 and returns true if userID is currently
+// suspended, so sign-in paths can stop before issuing tokens.
+func (h *Handler) rejectSuspended(c *gin.Context, userID int) bool {
+	var suspended bool
+	var suspendedUntil sql.NullTime
+	var reason string
+	err := h.db.QueryRow(`
+		SELECT NOT `+notSuspendedSQL+`, u.suspended_until, COALESCE(u.suspension_reason, '')
+		FROM users u WHERE u.id = $1`,
+		userID).Scan(&suspended, &suspendedUntil, &reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return true
+	}
+
+	if !suspended {
+		return false
+	}
+
+	response := gin.H{"error": "Account suspended", "reason": reason}
+	if suspendedUntil.Valid {
+		response["suspended_until"] = suspendedUntil.Time
+	}
+	c.JSON(http.StatusForbidden, response)
+	return true
+}
+
+// enforceSuspension cuts a newly suspended user off straight away rather than
+// when their cached sessions expire. Their posts drop out of feeds on their
+// own, since timelines are filtered for suspended authors as they're read.
+func (h *Handler) enforceSuspension(userID int) {
+	rows, err := h.db.Query("SELECT id FROM sessions WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err == nil {
+		for rows.Next() {
+			var sessionID string
+			if rows.Scan(&sessionID) == nil {
+				h.redis.Delete(redis.SessionKey(sessionID))
+			}
+		}
+		rows.Close()
+	}
+
+	h.hub.DisconnectUser(userID)
+}
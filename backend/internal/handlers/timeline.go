@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"pulsefeed-backend/internal/cursor"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+
+	"github.com/lib/pq"
+)
+
+// Home timelines are Redis sorted sets of post IDs scored by created_at in
+// microseconds. Posts are pushed into followers' timelines when created,
+// except for accounts with at least cfg.FeedFanoutThreshold followers, whose
+// posts are merged in from the database when a feed is read.
+const (
+	timelineSize = 800
+	timelineTTL  = 24 * time.Hour
+	postCacheTTL = 15 * time.Minute
+	// timelineTieSlack over-fetches a few entries so posts sharing the
+	// cursor's timestamp can be skipped without coming up short.
+	timelineTieSlack = 5
+)
+
+// errTimelineExhausted means the page reaches past the oldest post kept in
+// the timeline, so it has to come from the database instead.
+var errTimelineExhausted = errors.New("timeline exhausted")
+
+type timelineItem struct {
+	postID    int
+	createdAt time.Time
+}
+
+func timelineScore(t time.Time) float64 {
+	return float64(t.UnixMicro())
+}
+
+// fanOutPost caches a new post and pushes it into its author's timeline and,
+// unless the author is over the fan-out threshold, their followers'.
+func (h *Handler) fanOutPost(post *models.Post) {
+	h.redis.Set(redis.PostCacheKey(post.ID), post, postCacheTTL)
+
+	keys := []string{redis.TimelineKey(post.UserID)}
+
+	var followers int
+	err := h.db.QueryRow("SELECT followers_count FROM users WHERE id = $1", post.UserID).Scan(&followers)
+	if err == nil && followers < h.cfg.FeedFanoutThreshold {
+		rows, err := h.db.Query("SELECT follower_id FROM follows WHERE following_id = $1", post.UserID)
+		if err == nil {
+			for rows.Next() {
+				var followerID int
+				if rows.Scan(&followerID) == nil {
+					keys = append(keys, redis.TimelineKey(followerID))
+				}
+			}
+			rows.Close()
+		}
+	}
+
+	if err := h.redis.PushTimelines(keys, post.ID, timelineScore(post.CreatedAt), timelineSize); err != nil {
+		log.Printf("Failed to fan out post %d: %v", post.ID, err)
+	}
+}
+
+// timelinePage returns up to pg.limit+1 posts for userID's feed after the
+// page's cursor, merging the stored timeline with followed celebrities.
+func (h *Handler) timelinePage(userID int, pg page) ([]timelineItem, error) {
+	celebrities, err := h.followedCelebrities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := redis.TimelineKey(userID)
+	if !h.redis.Exists(key) {
+		if err := h.rebuildTimeline(userID, celebrities); err != nil {
+			return nil, err
+		}
+	}
+
+	want := pg.limit + 1
+	maxScore := math.Inf(1)
+	if pg.after != nil {
+		maxScore = timelineScore(pg.after.CreatedAt)
+	}
+
+	count := int64(want + timelineTieSlack)
+	entries, err := h.redis.TimelineRange(key, maxScore, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// Running out early is only the real end of the feed if nothing was
+	// trimmed off the end of the timeline.
+	if int64(len(entries)) < count {
+		size, err := h.redis.TimelineLen(key)
+		if err != nil {
+			return nil, err
+		}
+		if size >= timelineSize {
+			return nil, errTimelineExhausted
+		}
+	}
+
+	items := make([]timelineItem, 0, len(entries)+want)
+	for _, entry := range entries {
+		items = append(items, timelineItem{
+			postID:    entry.PostID,
+			createdAt: time.UnixMicro(int64(entry.Score)).UTC(),
+		})
+	}
+
+	if len(celebrities) > 0 {
+		afterCreatedAt, afterID := pg.afterArgs()
+		rows, err := h.db.Query(`
+			SELECT p.id, p.created_at
+			FROM posts p
+			WHERE p.user_id = ANY($1) AND p.deleted_at IS NULL
+			  AND ($2::timestamp IS NULL OR (p.created_at, p.id) < ($2::timestamp, $3::int))
+			ORDER BY p.created_at DESC, p.id DESC
+			LIMIT $4`,
+			pq.Array(celebrities), afterCreatedAt, afterID, want)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var item timelineItem
+			if rows.Scan(&item.postID, &item.createdAt) == nil {
+				items = append(items, item)
+			}
+		}
+		rows.Close()
+	}
+
+	return mergeTimeline(items, pg.after, want), nil
+}
+
+// mergeTimeline sorts items newest first, drops duplicates and anything not
+// strictly after the cursor, and keeps at most n.
+func mergeTimeline(items []timelineItem, after *cursor.Position, n int) []timelineItem {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].createdAt.Equal(items[j].createdAt) {
+			return items[i].createdAt.After(items[j].createdAt)
+		}
+		return items[i].postID > items[j].postID
+	})
+
+	merged := make([]timelineItem, 0, n)
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if len(merged) == n {
+			break
+		}
+		if seen[item.postID] {
+			continue
+		}
+		if after != nil && !item.createdAt.Before(after.CreatedAt) &&
+			!(item.createdAt.Equal(after.CreatedAt) && item.postID < after.ID) {
+			continue
+		}
+		seen[item.postID] = true
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// rebuildTimeline reloads userID's timeline from the database, leaving out
+// celebrities since their posts are merged in on read.
+func (h *Handler) rebuildTimeline(userID int, celebrities []int64) error {
+	key := redis.TimelineKey(userID)
+	if err := h.redis.StartTimelineRebuild(key); err != nil {
+		return err
+	}
+
+	rows, err := h.db.Query(`
+		SELECT p.id, p.created_at
+		FROM posts p
+		WHERE (p.user_id = $1 OR p.user_id IN (
+			SELECT following_id FROM follows WHERE follower_id = $1
+		)) AND NOT (p.user_id = ANY($2)) AND p.deleted_at IS NULL
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3`,
+		userID, pq.Array(celebrities), timelineSize)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var entries []redis.TimelineEntry
+	for rows.Next() {
+		var postID int
+		var createdAt time.Time
+		if rows.Scan(&postID, &createdAt) == nil {
+			entries = append(entries, redis.TimelineEntry{PostID: postID, Score: timelineScore(createdAt)})
+		}
+	}
+
+	return h.redis.FinishTimelineRebuild(key, entries, timelineTTL)
+}
+
+// followedCelebrities lists the accounts userID follows that are at or over
+// the fan-out threshold.
+func (h *Handler) followedCelebrities(userID int) ([]int64, error) {
+	rows, err := h.db.Query(`
+		SELECT f.following_id
+		FROM follows f
+		JOIN users u ON u.id = f.following_id
+		WHERE f.follower_id = $1 AND u.followers_count >= $2`,
+		userID, h.cfg.FeedFanoutThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	celebrities := []int64{}
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			celebrities = append(celebrities, id)
+		}
+	}
+	return celebrities, nil
+}
+
+// hydratePosts loads postIDs in order for viewerID. Visibility, authors and
+// the viewer's reaction and bookmark are read from the database every time,
+// so profile edits show up at once; post bodies come from the post cache,
+// falling back to the database for misses.
+func (h *Handler) hydratePosts(viewerID int, postIDs []int) ([]*models.Post, error) {
+	posts := []*models.Post{}
+	if len(postIDs) == 0 {
+		return posts, nil
+	}
+
+	ids := make([]int64, len(postIDs))
+	for i, id := range postIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := h.db.Query(`
+		SELECT p.id, u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
+		       u.created_at, u.updated_at,
+		       `+viewerSQL("$2")+`
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($1) AND p.deleted_at IS NULL AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2"),
+		pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[int]*models.User, len(postIDs))
+	reactions := make(map[int]string, len(postIDs))
+	bookmarked := make(map[int]bool, len(postIDs))
+	for rows.Next() {
+		var id int
+		var author models.User
+		var reaction string
+		var isBookmarked bool
+		err := rows.Scan(&id, &author.ID, &author.Username, &author.Email, &author.FullName, &author.Bio,
+			&author.Avatar, &author.IsVerified, &author.CreatedAt, &author.UpdatedAt,
+			&reaction, &isBookmarked)
+		if err == nil {
+			authors[id] = &author
+			reactions[id] = reaction
+			bookmarked[id] = isBookmarked
+		}
+	}
+	rows.Close()
+
+	visible := make([]int, 0, len(reactions))
+	keys := make([]string, 0, len(reactions))
+	for _, id := range postIDs {
+		if _, ok := reactions[id]; ok {
+			visible = append(visible, id)
+			keys = append(keys, redis.PostCacheKey(id))
+		}
+	}
+
+	bodies := make(map[int]*models.Post, len(visible))
+	var misses []int64
+	cached, err := h.redis.MGet(keys)
+	for i, id := range visible {
+		var post models.Post
+		if err == nil && cached[i] != nil && json.Unmarshal(cached[i], &post) == nil {
+			bodies[id] = &post
+			continue
+		}
+		misses = append(misses, int64(id))
+	}
+
+	if len(misses) > 0 {
+		rows, err := h.db.Query(`
+			SELECT `+postColumns+`,
+			       '' AS reaction, false AS is_bookmarked
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			WHERE p.id = ANY($1)`,
+			pq.Array(misses))
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range h.scanFeedPosts(rows) {
+			bodies[post.ID] = post
+			h.redis.Set(redis.PostCacheKey(post.ID), post, postCacheTTL)
+		}
+		rows.Close()
+	}
+
+	for _, id := range visible {
+		post, ok := bodies[id]
+		if !ok {
+			continue
+		}
+		post.User = authors[id]
+		post.Reaction = reactions[id]
+		post.IsLiked = post.Reaction != ""
+		post.IsBookmarked = bookmarked[id]
+		posts = append(posts, post)
+	}
+	return h.attachEmbeds(viewerID, posts)
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+	"pulsefeed-backend/internal/token"
+	"pulsefeed-backend/internal/totp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	twoFactorIssuer       = "PulseFeed"
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorMaxAttempts  = 5
+	recoveryCodeCount     = 10
+)
+
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var account string
+	var enabled bool
+	err := h.db.QueryRow(`
+		SELECT COALESCE(NULLIF(email, ''), username), two_factor_enabled
+		FROM users WHERE id = $1`,
+		userID).Scan(&account, &enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+
+	recoveryCodes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate recovery codes"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Re-enrolling before confirmation simply replaces the pending secret and codes.
+	if _, err := tx.Exec("UPDATE users SET two_factor_secret = $1 WHERE id = $2", secret, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+		return
+	}
+	for _, code := range recoveryCodes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userID, sha256Hex(code)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start enrollment"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollResponse{
+		Secret:        secret,
+		OTPAuthURL:    totp.URL(twoFactorIssuer, account, secret),
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req models.TwoFactorConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var secret sql.NullString
+	var enabled bool
+	err := h.db.QueryRow("SELECT two_factor_secret, two_factor_enabled FROM users WHERE id = $1",
+		userID).Scan(&secret, &enabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if !secret.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor enrollment first"})
+		return
+	}
+
+	if _, ok := totp.Validate(secret.String, req.Code, time.Now()); !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	_, err = h.db.Exec("UPDATE users SET two_factor_enabled = true, updated_at = CURRENT_TIMESTAMP WHERE id = $1", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A code or recovery code is required"})
+		return
+	}
+
+	claims, err := h.tokens.Parse(req.ChallengeToken, token.TypeTwoFactor)
+	if err != nil || claims.ID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	attempts, err := h.redis.Incr(redis.TwoFactorAttemptsKey(claims.ID), twoFactorChallengeTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return
+	}
+	if attempts > twoFactorMaxAttempts {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, sign in again"})
+		return
+	}
+
+	var secret sql.NullString
+	var enabled bool
+	err = h.db.QueryRow("SELECT two_factor_secret, two_factor_enabled FROM users WHERE id = $1",
+		claims.UserID).Scan(&secret, &enabled)
+	if err != nil || !enabled || !secret.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	// Codes are checked before the challenge is consumed so a typo doesn't
+	// force a fresh sign-in, but only burned after, so two requests racing on
+	// one challenge can't both use up a code.
+	var step int64
+	codeHash := sha256Hex(normalizeRecoveryCode(req.RecoveryCode))
+	if req.Code != "" {
+		var ok bool
+		step, ok = totp.Validate(secret.String, req.Code, time.Now())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+	} else {
+		var unused bool
+		err := h.db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL)`,
+			claims.UserID, codeHash).Scan(&unused)
+		if err != nil || !unused {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid recovery code"})
+			return
+		}
+	}
+
+	// A challenge can only be exchanged for tokens once.
+	fresh, err := h.redis.SetNX(redis.TwoFactorChallengeUsedKey(claims.ID), true, twoFactorChallengeTTL)
+	if err != nil || !fresh {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge"})
+		return
+	}
+
+	if req.Code != "" {
+		// Each code is accepted once even though it stays valid for its whole window.
+		fresh, err := h.redis.SetNX(redis.TwoFactorUsedStepKey(claims.UserID, step), true, 2*twoFactorChallengeTTL)
+		if err != nil || !fresh {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Code has already been used"})
+			return
+		}
+	} else {
+		var codeID int
+		err := h.db.QueryRow(`
+			UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			RETURNING id`,
+			claims.UserID, codeHash).Scan(&codeID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid recovery code"})
+			return
+		}
+	}
+
+	user, err := h.getUserWithCounts(claims.UserID, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	if h.rejectSuspended(c, user.ID) {
+		return
+	}
+
+	accessToken, refreshToken, err := h.generateTokens(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// completeSignIn finishes a first-factor sign-in (password, Google or SMS).
+// Suspended accounts are turned away and accounts with 2FA on only get a
+// challenge to exchange at /auth/2fa/verify; everyone else gets tokens,
+// sent with status.
+func (h *Handler) completeSignIn(c *gin.Context, user *models.User, status int) {
+	if h.rejectSuspended(c, user.ID) {
+		return
+	}
+
+	var twoFactorEnabled bool
+	err := h.db.QueryRow("SELECT two_factor_enabled FROM users WHERE id = $1", user.ID).Scan(&twoFactorEnabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if twoFactorEnabled {
+		challengeToken, err := h.issueTwoFactorChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
+
+	accessToken, refreshToken, err := h.generateTokens(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	c.JSON(status, models.AuthResponse{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+func (h *Handler) issueTwoFactorChallenge(userID int) (string, error) {
+	now := time.Now()
+	return h.tokens.Sign(&token.Claims{
+		UserID: userID,
+		Type:   token.TypeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(twoFactorChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+}
+
+// generateRecoveryCodes returns codes formatted as "xxxx-xxxx".
+func generateRecoveryCodes(n int) ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) == 8 && !strings.Contains(code, "-") {
+		code = code[:4] + "-" + code[4:]
+	}
+	return code
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 func (h *Handler) UploadMedia(c *gin.Context) {
@@ -39,7 +40,7 @@ func (h *Handler) UploadMedia(c *gin.Context) {
 	filename := uuid.New().String() + ext
 
 	// Create upload directory if it doesn't exist
-	uploadDir := "./uploads"
+	uploadDir := h.cfg.UploadPath
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
 		return
@@ -63,6 +64,14 @@ func (h *Handler) UploadMedia(c *gin.Context) {
 
 	// Return file URL
 	fileURL := fmt.Sprintf("/uploads/%s", filename)
+
+	// Record the uploader so only they can attach the file to posts
+	if _, err := h.db.Exec("INSERT INTO uploads (user_id, url) VALUES ($1, $2)", c.GetInt("user_id"), fileURL); err != nil {
+		dst.Close()
+		os.Remove(filePath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
 	
 	c.JSON(http.StatusOK, gin.H{
 		"url":  fileURL,
@@ -70,6 +79,44 @@ func (h *Handler) UploadMedia(c *gin.Context) {
 	})
 }
 
+// checkPostMedia makes sure every /uploads/ URL in urls was uploaded by
+// userID or is in allowed (the media a post already has), writing a 400 and
+// returning false if not. Other URLs point off-site and are left alone.
+func (h *Handler) checkPostMedia(c *gin.Context, userID int, urls, allowed []string) bool {
+	kept := make(map[string]bool, len(allowed))
+	for _, url := range allowed {
+		kept[url] = true
+	}
+
+	var uploads []string
+	for _, url := range urls {
+		if strings.HasPrefix(url, "/uploads/") && !kept[url] {
+			uploads = append(uploads, url)
+		}
+	}
+	if len(uploads) == 0 {
+		return true
+	}
+
+	var owned int
+	err := h.db.QueryRow("SELECT COUNT(DISTINCT url) FROM uploads WHERE user_id = $1 AND url = ANY($2)",
+		userID, pq.Array(uploads)).Scan(&owned)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	distinct := make(map[string]bool, len(uploads))
+	for _, url := range uploads {
+		distinct[url] = true
+	}
+	if owned != len(distinct) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Media must be uploaded by you"})
+		return false
+	}
+	return true
+}
+
 func isValidMediaType(contentType string) bool {
 	validTypes := []string{
 		"image/jpeg",
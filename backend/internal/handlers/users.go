@@ -32,6 +32,11 @@ func (h *Handler) FollowUser(c *gin.Context) {
 		return
 	}
 
+	if blocked, err := h.isBlocked(userID, targetUserID); err != nil || blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot follow this user"})
+		return
+	}
+
 	// Insert follow relationship (ignore if already exists)
 	_, err = h.db.Exec(`
 		INSERT INTO follows (follower_id, following_id) 
@@ -50,7 +55,7 @@ func (h *Handler) FollowUser(c *gin.Context) {
 	// Clear cache
 	h.redis.Delete(redis.UserCacheKey(userID))
 	h.redis.Delete(redis.UserCacheKey(targetUserID))
-	h.redis.Delete(redis.FeedCacheKey(userID))
+	h.redis.DeleteTimeline(redis.TimelineKey(userID))
 
 	c.JSON(http.StatusOK, gin.H{"message": "User followed successfully"})
 }
@@ -73,7 +78,7 @@ func (h *Handler) UnfollowUser(c *gin.Context) {
 	// Clear cache
 	h.redis.Delete(redis.UserCacheKey(userID))
 	h.redis.Delete(redis.UserCacheKey(targetUserID))
-	h.redis.Delete(redis.FeedCacheKey(userID))
+	h.redis.DeleteTimeline(redis.TimelineKey(userID))
 
 	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed successfully"})
 }
@@ -88,7 +93,7 @@ func (h *Handler) GetFollowers(c *gin.Context) {
 	currentUserID := c.GetInt("user_id")
 
 	rows, err := h.db.Query(`
-		SELECT u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
 		       u.created_at, u.updated_at,
 		       EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id) as is_following
 		FROM users u
@@ -131,7 +136,7 @@ func (h *Handler) GetFollowing(c *gin.Context) {
 	currentUserID := c.GetInt("user_id")
 
 	rows, err := h.db.Query(`
-		SELECT u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
 		       u.created_at, u.updated_at,
 		       EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id) as is_following
 		FROM users u
@@ -164,6 +169,13 @@ func (h *Handler) GetFollowing(c *gin.Context) {
 	c.JSON(http.StatusOK, following)
 }
 
+// userSearchThreshold is the pg_trgm word similarity a name needs to match.
+// The extension's default of 0.6 misses most one-letter typos.
+const userSearchThreshold = "0.3"
+
+// likeEscaper escapes LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (h *Handler) SearchUsers(c *gin.Context) {
 	query := strings.TrimSpace(c.Query("q"))
 	if query == "" {
@@ -180,28 +192,48 @@ func (h *Handler) SearchUsers(c *gin.Context) {
 		}
 	}
 
-	searchTerm := "%" + strings.ToLower(query) + "%"
+	// Matching runs on pg_trgm word similarity, so typos and partial names
+	// still match and the trigram indexes on username and full_name are
+	// used. Prefix matches are kept for queries too short to have trigrams.
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SET LOCAL pg_trgm.word_similarity_threshold = " + userSearchThreshold); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
 
-	rows, err := h.db.Query(`
-		SELECT u.id, u.username, u.email, u.full_name, u.bio, u.avatar, u.is_verified,
+	prefix := likeEscaper.Replace(query) + "%"
+
+	// Accounts the viewer follows rank above ones that follow the viewer,
+	// which rank above strangers with an equally good match.
+	rows, err := tx.Query(`
+		SELECT u.id, u.username, COALESCE(u.email, ''), u.full_name, u.bio, u.avatar, u.is_verified,
 		       u.created_at, u.updated_at,
-		       (SELECT COUNT(*) FROM follows WHERE following_id = u.id) as followers_count,
-		       (SELECT COUNT(*) FROM follows WHERE follower_id = u.id) as following_count,
-		       (SELECT COUNT(*) FROM posts WHERE user_id = u.id) as posts_count,
-		       CASE WHEN $2 != u.id THEN 
-		           EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id)
-		       ELSE false END as is_following
+		       u.followers_count, u.following_count, u.posts_count,
+		       rel.is_following
 		FROM users u
-		WHERE LOWER(u.username) LIKE $1 OR LOWER(u.full_name) LIKE $1
-		ORDER BY 
-		    CASE WHEN LOWER(u.username) = LOWER($3) THEN 1
-		         WHEN LOWER(u.username) LIKE LOWER($3) || '%' THEN 2
-		         WHEN LOWER(u.full_name) = LOWER($3) THEN 3
-		         WHEN LOWER(u.full_name) LIKE LOWER($3) || '%' THEN 4
-		         ELSE 5 END,
-		    followers_count DESC
+		CROSS JOIN LATERAL (
+		    SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id) AS is_following,
+		           EXISTS(SELECT 1 FROM follows WHERE follower_id = u.id AND following_id = $2) AS follows_viewer
+		) rel
+		WHERE ($1 <% u.username OR $1 <% u.full_name OR u.username ILIKE $3 OR u.full_name ILIKE $3)
+		  AND `+notSuspendedSQL+` AND NOT `+blockedSQL("$2")+`
+		ORDER BY
+		    GREATEST(word_similarity($1, u.username), word_similarity($1, u.full_name))
+		    + CASE WHEN LOWER(u.username) = LOWER($1) THEN 1
+		           WHEN u.username ILIKE $3 OR u.full_name ILIKE $3 THEN 0.5
+		           ELSE 0 END
+		    + CASE WHEN rel.is_following THEN 0.3 ELSE 0 END
+		    + CASE WHEN rel.follows_viewer THEN 0.15 ELSE 0 END DESC,
+		    u.followers_count DESC,
+		    u.id
 		LIMIT $4`,
-		searchTerm, currentUserID, query, limit)
+		query, currentUserID, prefix, limit)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/redis"
+	"pulsefeed-backend/internal/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	verificationLinkTTL       = 24 * time.Hour
+	verificationEmailMaxSends = 3
+	verificationEmailWindow   = time.Hour
+)
+
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	user, err := h.getUserWithCounts(userID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+
+	if user.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account has no email address"})
+		return
+	}
+	if user.IsVerified {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+
+	sends, err := h.redis.Incr(redis.VerificationEmailSendsKey(userID), verificationEmailWindow)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	if sends > verificationEmailMaxSends {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many verification emails, try again later"})
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+func (h *Handler) ConfirmEmail(c *gin.Context) {
+	tokenString := c.Query("token")
+	if tokenString == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token required"})
+		return
+	}
+
+	claims, err := h.tokens.Parse(tokenString, token.TypeEmailVerification)
+	if err != nil || claims.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	// Matching on the email too means a link stops working once the address changes.
+	result, err := h.db.Exec(`
+		UPDATE users SET is_verified = true, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND LOWER(email) = LOWER($2)`,
+		claims.UserID, claims.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+		return
+	}
+
+	h.redis.Delete(redis.UserCacheKey(claims.UserID))
+
+	h.hub.BroadcastToUser(claims.UserID, map[string]interface{}{
+		"type": "email_verified",
+		"data": map[string]interface{}{
+			"user_id": claims.UserID,
+			"email":   claims.Email,
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (h *Handler) sendVerificationEmail(user *models.User) error {
+	now := time.Now()
+	verificationToken, err := h.tokens.Sign(&token.Claims{
+		UserID: user.ID,
+		Type:   token.TypeEmailVerification,
+		Email:  user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(verificationLinkTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s",
+		strings.TrimRight(h.cfg.AppBaseURL, "/"), url.QueryEscape(verificationToken))
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your PulseFeed email address by opening this link:\n\n%s\n\n"+
+		"The link expires in %d hours. If you didn't create an account, you can ignore this email.\n",
+		user.FullName, link, int(verificationLinkTTL.Hours()))
+
+	return h.mailer.Send(user.Email, "Verify your PulseFeed email", body)
+}
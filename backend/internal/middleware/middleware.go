@@ -1,11 +1,13 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"pulsefeed-backend/internal/token"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func CORS() gin.HandlerFunc {
@@ -13,6 +15,7 @@ func CORS() gin.HandlerFunc {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Expose-Headers", "X-Next-Cursor, Deprecation")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -23,7 +26,17 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-func AuthRequired() gin.HandlerFunc {
+// ErrAccountSuspended is returned by a SessionValidator when the session is
+// fine but its user is currently suspended.
+var ErrAccountSuspended = errors.New("account suspended")
+
+// SessionValidator reports whether the session a token was issued under is
+// still active.
+type SessionValidator interface {
+	ValidateSession(userID int, sessionID string) error
+}
+
+func AuthRequired(tokens *token.Service, sessions SessionValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -39,34 +52,50 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte("your-super-secret-jwt-key"), nil // Should come from config
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := tokens.Parse(tokenString, token.TypeAccess)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if claims.UserID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
 			c.Abort()
 			return
 		}
 
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+		if err := sessions.ValidateSession(claims.UserID, claims.SessionID); err != nil {
+			if errors.Is(err, ErrAccountSuspended) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", int(userID))
+		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
+
+// RequireRole must run after AuthRequired and only lets through users whose
+// token carries one of roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		c.Abort()
+	}
+}
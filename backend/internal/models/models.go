@@ -19,6 +19,12 @@ type User struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 	
+	// Moderation fields, only populated for admin endpoints
+	Role             string     `json:"role,omitempty" db:"role"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty" db:"suspended_at"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty" db:"suspended_until"`
+	SuspensionReason string     `json:"suspension_reason,omitempty" db:"suspension_reason"`
+	
 	// Computed fields
 	FollowersCount int  `json:"followers_count,omitempty"`
 	FollowingCount int  `json:"following_count,omitempty"`
@@ -32,14 +38,47 @@ type Post struct {
 	Content     string    `json:"content" db:"content"`
 	MediaURLs   MediaURLs `json:"media_urls" db:"media_urls"`
 	MediaType   string    `json:"media_type" db:"media_type"`
+	Mentions    Mentions  `json:"mentions" db:"mentions"`
 	LikesCount  int       `json:"likes_count" db:"likes_count"`
+	ReactionCounts ReactionCounts `json:"reaction_counts" db:"reaction_counts"`
 	CommentsCount int     `json:"comments_count" db:"comments_count"`
+	RepostsCount int      `json:"reposts_count" db:"reposts_count"`
+	RepostOfID  *int      `json:"repost_of_id,omitempty" db:"repost_of_id"`
+	QuoteOfID   *int      `json:"quote_of_id,omitempty" db:"quote_of_id"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	EditedAt    *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	Edited      bool      `json:"edited"`
+	Deleted     bool      `json:"deleted,omitempty"`
 	
 	// Joined fields
-	User      *User `json:"user,omitempty"`
-	IsLiked   bool  `json:"is_liked,omitempty"`
+	User      *User  `json:"user,omitempty"`
+	Reaction  string `json:"reaction,omitempty"`
+	
+	// Set when the viewer has reacted with any kind, for clients that
+	// predate reactions
+	IsLiked      bool `json:"is_liked,omitempty"`
+	IsBookmarked bool `json:"is_bookmarked,omitempty"`
+	
+	// The post a repost shares, or the post a quote embeds. A quoted post
+	// that has since been deleted comes back as a tombstone with Deleted set.
+	RepostOf   *Post `json:"repost_of,omitempty"`
+	QuotedPost *Post `json:"quoted_post,omitempty"`
+	
+	// Set on posts in the ranked feed
+	Ranking *RankingExplanation `json:"ranking,omitempty"`
+	
+	// Set on posts in search results: the matching part of the content,
+	// HTML-escaped with matches wrapped in <mark>
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// RankingExplanation says why a post was placed where it was in the ranked feed.
+type RankingExplanation struct {
+	Ranker  string             `json:"ranker"`
+	Score   float64            `json:"score"`
+	Factors map[string]float64 `json:"factors"`
+	Reasons []string           `json:"reasons"`
 }
 
 type MediaURLs []string
@@ -67,16 +106,114 @@ func (m MediaURLs) Value() (driver.Value, error) {
 	return json.Marshal(m)
 }
 
-type Comment struct {
+// Mention is an @username in a post or comment that resolved to a user.
+// Start and End are character offsets into the content, Start at the '@'.
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+type Mentions []Mention
+
+func (m *Mentions) Scan(value interface{}) error {
+	if value == nil {
+		*m = Mentions{}
+		return nil
+	}
+	
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return errors.New("cannot scan into Mentions")
+	}
+}
+
+func (m Mentions) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(m)
+}
+
+// BookmarkCollection is a named group of a user's bookmarks.
+type BookmarkCollection struct {
+	ID             int       `json:"id" db:"id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	Name           string    `json:"name" db:"name"`
+	BookmarksCount int       `json:"bookmarks_count"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReactionLike is the reaction the like endpoints add.
+const ReactionLike = "like"
+
+// ReactionCounts is how many users reacted to a post with each kind.
+// Kinds nobody has used are left out.
+type ReactionCounts map[string]int
+
+func (r *ReactionCounts) Scan(value interface{}) error {
+	if value == nil {
+		*r = ReactionCounts{}
+		return nil
+	}
+	
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return errors.New("cannot scan into ReactionCounts")
+	}
+}
+
+func (r ReactionCounts) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(r)
+}
+
+// PostRevision is a superseded version of an edited post. CreatedAt is when
+// it was replaced.
+type PostRevision struct {
 	ID        int       `json:"id" db:"id"`
 	PostID    int       `json:"post_id" db:"post_id"`
-	UserID    int       `json:"user_id" db:"user_id"`
 	Content   string    `json:"content" db:"content"`
+	MediaURLs MediaURLs `json:"media_urls" db:"media_urls"`
+	MediaType string    `json:"media_type" db:"media_type"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TrendingHashtag is a tag and how many new posts used it in the trending
+// window.
+type TrendingHashtag struct {
+	Tag  string `json:"tag"`
+	Uses int    `json:"uses"`
+}
+
+type Comment struct {
+	ID           int        `json:"id" db:"id"`
+	PostID       int        `json:"post_id" db:"post_id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	ParentID     *int       `json:"parent_id,omitempty" db:"parent_id"`
+	Content      string     `json:"content" db:"content"`
+	Mentions     Mentions   `json:"mentions" db:"mentions"`
+	RepliesCount int        `json:"replies_count" db:"replies_count"`
+	LikesCount   int        `json:"likes_count" db:"likes_count"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	EditedAt     *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	Edited       bool       `json:"edited"`
 	
 	// Joined fields
-	User *User `json:"user,omitempty"`
+	User    *User `json:"user,omitempty"`
+	IsLiked bool  `json:"is_liked,omitempty"`
 }
 
 type Like struct {
@@ -107,12 +244,45 @@ type Notification struct {
 	Post  *Post `json:"post,omitempty"`
 }
 
+type Session struct {
+	ID         string    `json:"id" db:"id"`
+	DeviceName string    `json:"device_name" db:"device_name"`
+	UserAgent  string    `json:"user_agent" db:"user_agent"`
+	IPAddress  string    `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	
+	// Computed fields
+	IsCurrent bool `json:"is_current"`
+}
+
+type AuditLogEntry struct {
+	ID         int             `json:"id" db:"id"`
+	ActorID    *int            `json:"actor_id" db:"actor_id"`
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   int             `json:"target_id" db:"target_id"`
+	Details    json.RawMessage `json:"details" db:"details"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
 type NotificationType string
 
 const (
-	NotificationLike    NotificationType = "like"
-	NotificationComment NotificationType = "comment"
-	NotificationFollow  NotificationType = "follow"
+	NotificationLike        NotificationType = "like"
+	NotificationComment     NotificationType = "comment"
+	NotificationFollow      NotificationType = "follow"
+	NotificationMention     NotificationType = "mention"
+	NotificationRepost      NotificationType = "repost"
+	NotificationReply       NotificationType = "reply"
+	NotificationCommentLike NotificationType = "comment_like"
+	NotificationReaction    NotificationType = "reaction"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
 )
 
 // Request/Response models
@@ -134,13 +304,110 @@ type AuthResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type GoogleAuthRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+}
+
+type SendOTPRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+type VerifyOTPRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	OTP         string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ChangePasswordRequest needs CurrentPassword, or for an account with no
+// password yet, GoogleIDToken or an OTP sent to the account's phone number.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	GoogleIDToken   string `json:"google_id_token"`
+	OTP             string `json:"otp"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURL    string   `json:"otpauth_url"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+	// DurationHours of 0 suspends the account until it is lifted manually.
+	DurationHours int `json:"duration_hours" binding:"min=0"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+type TakedownPostRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type CreatePostRequest struct {
 	Content   string   `json:"content" binding:"required,max=280"`
 	MediaURLs []string `json:"media_urls,omitempty"`
 	MediaType string   `json:"media_type,omitempty"`
+	QuoteOfID *int     `json:"quote_of_id,omitempty"`
+}
+
+// UpdatePostRequest replaces a post's content. Media is left as it was unless
+// media_urls is sent.
+type UpdatePostRequest struct {
+	Content   string    `json:"content" binding:"required,max=280"`
+	MediaURLs *[]string `json:"media_urls,omitempty"`
+	MediaType *string   `json:"media_type,omitempty"`
 }
 
 type CreateCommentRequest struct {
+	Content  string `json:"content" binding:"required,max=280"`
+	ParentID *int   `json:"parent_id,omitempty"`
+}
+
+// BookmarkRequest saves a post, in a collection if CollectionID is set.
+type BookmarkRequest struct {
+	CollectionID *int `json:"collection_id,omitempty"`
+}
+
+type CreateBookmarkCollectionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type ReactRequest struct {
+	Kind string `json:"kind" binding:"required"`
+}
+
+type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required,max=280"`
 }
 
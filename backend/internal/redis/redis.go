@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -72,9 +74,227 @@ func (c *Client) SetNX(key string, value interface{}, expiration time.Duration)
 	return c.rdb.SetNX(c.ctx, key, data, expiration).Result()
 }
 
+// Incr increments a counter and starts its expiration window on first use.
+func (c *Client) Incr(key string, expiration time.Duration) (int64, error) {
+	n, err := c.rdb.Incr(c.ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if n == 1 {
+		c.rdb.Expire(c.ctx, key, expiration)
+	}
+
+	return n, nil
+}
+
+// MGet returns the raw JSON stored at each key, nil where a key is missing.
+func (c *Client) MGet(keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := c.rdb.MGet(c.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]byte, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[i] = []byte(s)
+		}
+	}
+	return result, nil
+}
+
+// TimelineEntry is a post in a timeline sorted set, scored by creation time.
+type TimelineEntry struct {
+	PostID int
+	Score  float64
+}
+
+// pushTimeline only adds to timelines that already exist, or are being
+// rebuilt. A missing timeline is rebuilt in full on its next read, and
+// pushing into it first would make a partial timeline look complete.
+var pushTimeline = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call('EXISTS', key) == 1 then
+		redis.call('ZADD', key, ARGV[1], ARGV[2])
+		redis.call('ZREMRANGEBYRANK', key, 0, -tonumber(ARGV[3]) - 1)
+	end
+end
+return 0
+`)
+
+// finishTimeline adds the rebuilt entries (score, member pairs) to the
+// rebuild key, which already holds anything pushed while the rebuild ran, and
+// moves it into place. The rebuild key is gone if the timeline was dropped
+// or another rebuild finished first, and then nothing is written.
+var finishTimeline = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	return 0
+end
+for i = 2, #ARGV, 2 do
+	redis.call('ZADD', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+redis.call('RENAME', KEYS[2], KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+return 1
+`)
+
+// timelineRebuildTTL bounds how long a rebuild key outlives a rebuild that
+// never finished.
+const timelineRebuildTTL = time.Minute
+
+func timelineRebuildKey(key string) string {
+	return key + ":rebuild"
+}
+
+// PushTimelines adds postID to every existing timeline in keys, keeping the
+// newest maxLen entries of each.
+func (c *Client) PushTimelines(keys []string, postID int, score float64, maxLen int64) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	for _, key := range keys {
+		pushTimeline.Eval(c.ctx, pipe, []string{key, timelineRebuildKey(key)}, score, postID, maxLen)
+	}
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// StartTimelineRebuild must be called before reading the entries for
+// FinishTimelineRebuild, so posts pushed in the meantime are collected
+// rather than lost. The placeholder entry also means the finished key exists
+// even when there are no entries, so readers know it has been built.
+func (c *Client) StartTimelineRebuild(key string) error {
+	rebuildKey := timelineRebuildKey(key)
+
+	pipe := c.rdb.TxPipeline()
+	pipe.ZAdd(c.ctx, rebuildKey, &redis.Z{Score: 0, Member: 0})
+	pipe.Expire(c.ctx, rebuildKey, timelineRebuildTTL)
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// FinishTimelineRebuild atomically swaps in the timeline started with
+// StartTimelineRebuild, made of entries and whatever was pushed since.
+func (c *Client) FinishTimelineRebuild(key string, entries []TimelineEntry, expiration time.Duration) error {
+	args := make([]interface{}, 0, 1+2*len(entries))
+	args = append(args, int64(expiration/time.Second))
+	for _, entry := range entries {
+		args = append(args, entry.Score, entry.PostID)
+	}
+
+	return finishTimeline.Run(c.ctx, c.rdb, []string{key, timelineRebuildKey(key)}, args...).Err()
+}
+
+// DeleteTimeline drops a timeline, and any rebuild of it in progress, so the
+// next read rebuilds it from scratch.
+func (c *Client) DeleteTimeline(key string) error {
+	return c.rdb.Del(c.ctx, key, timelineRebuildKey(key)).Err()
+}
+
+// TimelineRange returns up to count entries scored at or below maxScore,
+// newest first. The placeholder entry StartTimelineRebuild adds is left out.
+func (c *Client) TimelineRange(key string, maxScore float64, count int64) ([]TimelineEntry, error) {
+	max := "+inf"
+	if !math.IsInf(maxScore, 1) {
+		max = strconv.FormatFloat(maxScore, 'f', -1, 64)
+	}
+
+	results, err := c.rdb.ZRevRangeByScoreWithScores(c.ctx, key, &redis.ZRangeBy{
+		Min:   "(0",
+		Max:   max,
+		Count: count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TimelineEntry, 0, len(results))
+	for _, z := range results {
+		member, _ := z.Member.(string)
+		postID, err := strconv.Atoi(member)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, TimelineEntry{PostID: postID, Score: z.Score})
+	}
+	return entries, nil
+}
+
+func (c *Client) TimelineLen(key string) (int64, error) {
+	return c.rdb.ZCard(c.ctx, key).Result()
+}
+
+// ScoredMember is a sorted set member and its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// IncrMembers adds 1 to each member's score in the sorted set at key and
+// (re)starts its expiration.
+func (c *Client) IncrMembers(key string, members []string, expiration time.Duration) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	for _, member := range members {
+		pipe.ZIncrBy(c.ctx, key, 1, member)
+	}
+	pipe.Expire(c.ctx, key, expiration)
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// TopOfUnion returns the count highest scoring members of the union of the
+// sorted sets in keys, summing scores. The union is stored at dest for
+// expiration so repeated reads within that time don't recompute it.
+func (c *Client) TopOfUnion(dest string, keys []string, count int64, expiration time.Duration) ([]ScoredMember, error) {
+	if c.rdb.Exists(c.ctx, dest).Val() == 0 {
+		pipe := c.rdb.TxPipeline()
+		pipe.ZUnionStore(c.ctx, dest, &redis.ZStore{Keys: keys})
+		pipe.Expire(c.ctx, dest, expiration)
+		if _, err := pipe.Exec(c.ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := c.rdb.ZRevRangeWithScores(c.ctx, dest, 0, count-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ScoredMember, 0, len(results))
+	for _, z := range results {
+		member, _ := z.Member.(string)
+		members = append(members, ScoredMember{Member: member, Score: z.Score})
+	}
+	return members, nil
+}
+
 // Cache keys
-func FeedCacheKey(userID int) string {
-	return fmt.Sprintf("feed:%d", userID)
+func TimelineKey(userID int) string {
+	return fmt.Sprintf("timeline:%d", userID)
+}
+
+func RankedFeedKey(userID int) string {
+	return fmt.Sprintf("ranked_feed:%d", userID)
+}
+
+// HashtagBucketKey counts hashtag uses in one trending bucket.
+func HashtagBucketKey(bucket int64) string {
+	return fmt.Sprintf("hashtag_bucket:%d", bucket)
+}
+
+func TrendingHashtagsKey(window string, bucket int64) string {
+	return fmt.Sprintf("trending_hashtags:%s:%d", window, bucket)
 }
 
 func UserCacheKey(userID int) string {
@@ -84,3 +304,46 @@ func UserCacheKey(userID int) string {
 func PostCacheKey(postID int) string {
 	return fmt.Sprintf("post:%d", postID)
 }
+
+// OTP keys
+func OTPKey(phoneNumber string) string {
+	return fmt.Sprintf("otp:%s", phoneNumber)
+}
+
+func OTPAttemptsKey(phoneNumber string) string {
+	return fmt.Sprintf("otp_attempts:%s", phoneNumber)
+}
+
+func OTPSendsKey(phoneNumber string) string {
+	return fmt.Sprintf("otp_sends:%s", phoneNumber)
+}
+
+func VerificationEmailSendsKey(userID int) string {
+	return fmt.Sprintf("verification_email_sends:%d", userID)
+}
+
+func PasswordResetSendsKey(email string) string {
+	return fmt.Sprintf("password_reset_sends:%s", email)
+}
+
+// Two-factor keys
+func TwoFactorAttemptsKey(challengeID string) string {
+	return fmt.Sprintf("2fa_attempts:%s", challengeID)
+}
+
+func TwoFactorChallengeUsedKey(challengeID string) string {
+	return fmt.Sprintf("2fa_challenge_used:%s", challengeID)
+}
+
+func TwoFactorUsedStepKey(userID int, step int64) string {
+	return fmt.Sprintf("2fa_used:%d:%d", userID, step)
+}
+
+// Session keys
+func SessionKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+func SessionSeenKey(sessionID string) string {
+	return fmt.Sprintf("session_seen:%s", sessionID)
+}
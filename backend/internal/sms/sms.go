@@ -0,0 +1,58 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Sender delivers a text message to a phone number in E.164 format.
+type Sender interface {
+	Send(phoneNumber, message string) error
+}
+
+// New returns the sender selected by name. Production providers plug in
+// here; "log" and "file" are meant for development.
+func New(name, outboxPath string) (Sender, error) {
+	switch name {
+	case "", "log":
+		return LogSender{}, nil
+	case "file":
+		return NewFileSender(outboxPath), nil
+	}
+	return nil, fmt.Errorf("unknown SMS sender %q", name)
+}
+
+// LogSender writes messages to the server log instead of sending them.
+type LogSender struct{}
+
+func (LogSender) Send(phoneNumber, message string) error {
+	log.Printf("SMS to %s: %s", phoneNumber, message)
+	return nil
+}
+
+// FileSender appends messages to a file so tests and local tooling can read them.
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(phoneNumber, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms outbox: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), phoneNumber, message)
+	return err
+}
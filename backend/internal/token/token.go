@@ -0,0 +1,273 @@
+package token
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pulsefeed-backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TypeAccess            = "access"
+	TypeRefresh           = "refresh"
+	TypeEmailVerification = "email_verification"
+	TypeTwoFactor         = "two_factor_challenge"
+)
+
+// placeholderSecrets are the example secrets published in this repository's
+// docs and config. Anyone could sign tokens with them.
+var placeholderSecrets = map[string]bool{
+	"your-super-secret-jwt-key":                           true,
+	"your-super-secret-jwt-key-change-this-in-production": true,
+	"your-jwt-secret":                                     true,
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrWrongType    = errors.New("unexpected token type")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Claims is the payload of every token we issue.
+type Claims struct {
+	UserID    int    `json:"user_id"`
+	Type      string `json:"type"`
+	SessionID string `json:"sid,omitempty"`
+	Role      string `json:"role,omitempty"`
+	FamilyID  string `json:"fid,omitempty"`
+	Email     string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Key is a single signing or verification key identified by its kid.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	// NotAfter stops a retired key from verifying anything once passed.
+	// The zero value means the key never stops verifying.
+	NotAfter time.Time
+}
+
+// Service signs tokens with the active key and verifies them against every
+// configured key, so secrets can be rotated without invalidating sessions.
+type Service struct {
+	signing *Key
+	keys    map[string]*Key
+	methods []string
+}
+
+func NewService(cfg *config.Config) (*Service, error) {
+	active, err := activeKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		signing: active,
+		keys:    map[string]*Key{active.ID: active},
+	}
+
+	for _, spec := range strings.Split(cfg.JWTPreviousKeys, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		key, err := previousKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := s.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+		s.keys[key.ID] = key
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range s.keys {
+		if !seen[key.Method.Alg()] {
+			seen[key.Method.Alg()] = true
+			s.methods = append(s.methods, key.Method.Alg())
+		}
+	}
+
+	return s, nil
+}
+
+// Sign stamps the active kid into the header and signs claims with it.
+func (s *Service) Sign(claims *Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.signKey)
+}
+
+// Parse verifies tokenString and checks that it is of the expected type.
+func (s *Service) Parse(tokenString, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keyFunc, jwt.WithValidMethods(s.methods))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType {
+		// Tokens minted before typed claims existed carry no type and were
+		// only ever used as access tokens.
+		if !(claims.Type == "" && tokenType == TypeAccess) {
+			return nil, ErrWrongType
+		}
+	}
+
+	return claims, nil
+}
+
+func (s *Service) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = s.signing.ID
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if !key.NotAfter.IsZero() && time.Now().After(key.NotAfter) {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	return key.verifyKey, nil
+}
+
+func activeKey(cfg *config.Config) (*Key, error) {
+	kid := cfg.JWTKeyID
+	if kid == "" {
+		kid = "default"
+	}
+
+	switch strings.ToUpper(cfg.JWTAlgorithm) {
+	case "", "HS256":
+		if err := CheckSecret("JWT_SECRET", cfg.JWTSecret); err != nil {
+			return nil, err
+		}
+		return &Key{
+			ID:        kid,
+			Method:    jwt.SigningMethodHS256,
+			signKey:   []byte(cfg.JWTSecret),
+			verifyKey: []byte(cfg.JWTSecret),
+		}, nil
+
+	case "RS256":
+		pem, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt private key: %w", err)
+		}
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwt private key: %w", err)
+		}
+		return &Key{
+			ID:        kid,
+			Method:    jwt.SigningMethodRS256,
+			signKey:   private,
+			verifyKey: &private.PublicKey,
+		}, nil
+
+	case "EDDSA":
+		pem, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt private key: %w", err)
+		}
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwt private key: %w", err)
+		}
+		return &Key{
+			ID:        kid,
+			Method:    jwt.SigningMethodEdDSA,
+			signKey:   private,
+			verifyKey: private.(crypto.Signer).Public(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JWTAlgorithm)
+}
+
+// CheckSecret refuses an empty secret or one of the placeholders from the
+// docs, naming the setting it came from in the error.
+func CheckSecret(name, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	if placeholderSecrets[secret] {
+		return fmt.Errorf("%s is the example value from the docs; set it to a long random string", name)
+	}
+	return nil
+}
+
+// previousKey parses a retired key spec of the form
+// "kid|alg|material[|not-after]". For HS256 the material is the secret; for
+// RS256 and EdDSA it is the path to a PEM public key. not-after is RFC 3339.
+func previousKey(spec string) (*Key, error) {
+	parts := strings.Split(spec, "|")
+	if len(parts) < 3 || len(parts) > 4 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid JWT_PREVIOUS_KEYS entry %q", spec)
+	}
+
+	key := &Key{ID: parts[0]}
+
+	if len(parts) == 4 && parts[3] != "" {
+		notAfter, err := time.Parse(time.RFC3339, parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry for jwt key %q: %w", key.ID, err)
+		}
+		key.NotAfter = notAfter
+	}
+
+	switch strings.ToUpper(parts[1]) {
+	case "HS256":
+		if err := CheckSecret(fmt.Sprintf("secret for jwt key %q", key.ID), parts[2]); err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodHS256
+		key.verifyKey = []byte(parts[2])
+
+	case "RS256":
+		pem, err := os.ReadFile(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key for jwt key %q: %w", key.ID, err)
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key for jwt key %q: %w", key.ID, err)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = public
+
+	case "EDDSA":
+		pem, err := os.ReadFile(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key for jwt key %q: %w", key.ID, err)
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key for jwt key %q: %w", key.ID, err)
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		key.verifyKey = public
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q for jwt key %q", parts[1], key.ID)
+	}
+
+	return key, nil
+}
@@ -17,15 +17,24 @@ var upgrader = websocket.Upgrader{
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
+	direct     chan userMessage
 	register   chan *Client
 	unregister chan *Client
+	disconnect chan func(*Client) bool
 }
 
-type Client struct {
-	hub    *Hub
-	conn   *websocket.Conn
-	send   chan []byte
+// userMessage is a message for every connection of one user.
+type userMessage struct {
 	userID int
+	data   []byte
+}
+
+type Client struct {
+	hub       *Hub
+	conn      *websocket.Conn
+	send      chan []byte
+	userID    int
+	sessionID string
 }
 
 type Message struct {
@@ -38,8 +47,10 @@ func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[*Client]bool),
 		broadcast:  make(chan []byte),
+		direct:     make(chan userMessage),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		disconnect: make(chan func(*Client) bool),
 	}
 }
 
@@ -51,10 +62,13 @@ func (h *Hub) Run() {
 			log.Printf("Client connected: %d", client.userID)
 
 		case client := <-h.unregister:
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				log.Printf("Client disconnected: %d", client.userID)
+			h.remove(client)
+
+		case match := <-h.disconnect:
+			for client := range h.clients {
+				if match(client) {
+					h.remove(client)
+				}
 			}
 
 		case message := <-h.broadcast:
@@ -62,14 +76,36 @@ func (h *Hub) Run() {
 				select {
 				case client.send <- message:
 				default:
-					close(client.send)
-					delete(h.clients, client)
+					h.remove(client)
+				}
+			}
+
+		case message := <-h.direct:
+			for client := range h.clients {
+				if client.userID == message.userID {
+					select {
+					case client.send <- message.data:
+					default:
+						h.remove(client)
+					}
 				}
 			}
 		}
 	}
 }
 
+// remove drops client from the hub. Only Run touches clients, and this is
+// the only place send is closed, which makes writePump send a close frame
+// and drop the connection.
+func (h *Hub) remove(client *Client) {
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	delete(h.clients, client)
+	close(client.send)
+	log.Printf("Client disconnected: %d", client.userID)
+}
+
 func (h *Hub) BroadcastToUser(userID int, message interface{}) {
 	data, err := json.Marshal(message)
 	if err != nil {
@@ -77,15 +113,29 @@ func (h *Hub) BroadcastToUser(userID int, message interface{}) {
 		return
 	}
 
-	for client := range h.clients {
-		if client.userID == userID {
-			select {
-			case client.send <- data:
-			default:
-				close(client.send)
-				delete(h.clients, client)
-			}
-		}
+	h.direct <- userMessage{userID: userID, data: data}
+}
+
+// DisconnectSessions closes every connection opened with one of sessionIDs.
+func (h *Hub) DisconnectSessions(sessionIDs ...string) {
+	if len(sessionIDs) == 0 {
+		return
+	}
+
+	revoked := make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		revoked[id] = true
+	}
+
+	h.disconnect <- func(client *Client) bool {
+		return revoked[client.sessionID]
+	}
+}
+
+// DisconnectUser closes every connection belonging to userID.
+func (h *Hub) DisconnectUser(userID int) {
+	h.disconnect <- func(client *Client) bool {
+		return client.userID == userID
 	}
 }
 
@@ -135,12 +185,13 @@ func (c *Client) writePump() {
 	}
 }
 
-func (h *Hub) HandleWebSocket(conn *websocket.Conn, userID int) {
+func (h *Hub) HandleWebSocket(conn *websocket.Conn, userID int, sessionID string) {
 	client := &Client{
-		hub:    h,
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		userID: userID,
+		hub:       h,
+		conn:      conn,
+		send:      make(chan []byte, 256),
+		userID:    userID,
+		sessionID: sessionID,
 	}
 
 	h.register <- client
@@ -3,12 +3,20 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"pulsefeed-backend/internal/config"
+	"pulsefeed-backend/internal/cursor"
 	"pulsefeed-backend/internal/database"
+	"pulsefeed-backend/internal/google"
 	"pulsefeed-backend/internal/handlers"
+	"pulsefeed-backend/internal/mail"
 	"pulsefeed-backend/internal/middleware"
+	"pulsefeed-backend/internal/models"
+	"pulsefeed-backend/internal/ranking"
 	"pulsefeed-backend/internal/redis"
+	"pulsefeed-backend/internal/sms"
+	"pulsefeed-backend/internal/token"
 	"pulsefeed-backend/internal/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -44,8 +52,50 @@ func main() {
 	hub := websocket.NewHub()
 	go hub.Run()
 
+	// Initialize JWT signing service
+	tokens, err := token.NewService(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize token service:", err)
+	}
+
+	// Initialize Google ID token verifier
+	googleVerifier := google.NewVerifier(cfg.GoogleClientID, google.NewJWKSKeySource(google.CertsURL))
+
+	// Initialize SMS sender for phone OTP
+	smsSender, err := sms.New(cfg.SMSSender, cfg.SMSOutboxPath)
+	if err != nil {
+		log.Fatal("Failed to initialize SMS sender:", err)
+	}
+
+	// Initialize mailer for account emails
+	mailer, err := mail.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer:", err)
+	}
+
+	// Initialize signer for pagination cursors
+	if err := token.CheckSecret("CURSOR_SECRET (or JWT_SECRET)", cfg.CursorSecret); err != nil {
+		log.Fatal("Failed to initialize cursors:", err)
+	}
+	cursors := cursor.NewCodec(cfg.CursorSecret)
+
+	// Initialize rankers for the ranked feed
+	rankers, err := ranking.NewExperiment(cfg.RankingVariants, ranking.Weights{
+		HalfLife:     time.Duration(cfg.RankingHalfLifeHours * float64(time.Hour)),
+		Likes:        cfg.RankingLikeWeight,
+		Comments:     cfg.RankingCommentWeight,
+		Interactions: cfg.RankingInteractionWeight,
+		SecondDegree: cfg.RankingSecondDegreeWeight,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize feed ranking:", err)
+	}
+
 	// Initialize handlers
-	h := handlers.New(db, redisClient, hub)
+	h := handlers.New(cfg, db, redisClient, hub, tokens, googleVerifier, smsSender, mailer, cursors, rankers)
+
+	// Purge soft-deleted posts once their retention period is up
+	go h.RunPostPurge()
 
 	// Setup Gin router
 	r := gin.Default()
@@ -62,21 +112,38 @@ func main() {
 			auth.POST("/register", h.Register)
 			auth.POST("/login", h.Login)
 			auth.POST("/refresh", h.RefreshToken)
+			auth.POST("/logout", h.Logout)
 			auth.POST("/google", h.GoogleAuth)
+			auth.POST("/send-otp", h.SendOTP)
+			auth.POST("/verify-otp", h.VerifyOTP)
+			auth.GET("/verify-email", h.ConfirmEmail)
+			auth.POST("/password/forgot", h.ForgotPassword)
+			auth.POST("/password/reset", h.ResetPassword)
+			auth.POST("/2fa/verify", h.VerifyTwoFactor)
 		}
 
 		// Protected routes
 		protected := api.Group("/")
-		protected.Use(middleware.AuthRequired())
+		protected.Use(middleware.AuthRequired(tokens, h))
 		{
 			// User routes
 			users := protected.Group("/users")
 			{
 				users.GET("/me", h.GetProfile)
 				users.PUT("/me", h.UpdateProfile)
+				users.POST("/me/google", h.LinkGoogleAccount)
+				users.POST("/me/verification-email", h.ResendVerificationEmail)
+				users.PUT("/me/password", h.ChangePassword)
+				users.POST("/me/2fa", h.EnrollTwoFactor)
+				users.POST("/me/2fa/confirm", h.ConfirmTwoFactor)
+				users.GET("/me/sessions", h.ListSessions)
+				users.DELETE("/me/sessions", h.RevokeAllSessions)
+				users.DELETE("/me/sessions/:id", h.RevokeSession)
 				users.GET("/:id", h.GetUserProfile)
 				users.POST("/:id/follow", h.FollowUser)
 				users.DELETE("/:id/follow", h.UnfollowUser)
+				users.POST("/:id/block", h.BlockUser)
+				users.DELETE("/:id/block", h.UnblockUser)
 				users.GET("/:id/followers", h.GetFollowers)
 				users.GET("/:id/following", h.GetFollowing)
 				users.GET("/search", h.SearchUsers)
@@ -87,14 +154,51 @@ func main() {
 			{
 				posts.POST("/", h.CreatePost)
 				posts.GET("/feed", h.GetFeed)
+				posts.GET("/search", h.SearchPosts)
 				posts.GET("/:id", h.GetPost)
+				posts.PUT("/:id", h.UpdatePost)
+				posts.DELETE("/:id", h.DeletePost)
+				posts.GET("/:id/revisions", h.GetPostRevisions)
 				posts.POST("/:id/like", h.LikePost)
 				posts.DELETE("/:id/like", h.UnlikePost)
+				posts.POST("/:id/reactions", h.ReactToPost)
+				posts.DELETE("/:id/reactions", h.RemoveReaction)
+				posts.GET("/:id/likes", h.GetPostLikes)
+				posts.POST("/:id/bookmark", h.BookmarkPost)
+				posts.DELETE("/:id/bookmark", h.UnbookmarkPost)
+				posts.POST("/:id/repost", h.RepostPost)
+				posts.DELETE("/:id/repost", h.UnrepostPost)
 				posts.GET("/:id/comments", h.GetComments)
 				posts.POST("/:id/comments", h.CreateComment)
 				posts.GET("/user/:id", h.GetUserPosts)
 			}
 
+			// Comment routes
+			comments := protected.Group("/comments")
+			{
+				comments.PUT("/:id", h.UpdateComment)
+				comments.DELETE("/:id", h.DeleteComment)
+				comments.GET("/:id/replies", h.GetCommentReplies)
+				comments.POST("/:id/like", h.LikeComment)
+				comments.DELETE("/:id/like", h.UnlikeComment)
+			}
+
+			// Bookmark routes
+			bookmarks := protected.Group("/bookmarks")
+			{
+				bookmarks.GET("/", h.GetBookmarks)
+				bookmarks.GET("/collections", h.GetBookmarkCollections)
+				bookmarks.POST("/collections", h.CreateBookmarkCollection)
+				bookmarks.DELETE("/collections/:id", h.DeleteBookmarkCollection)
+			}
+
+			// Hashtag routes
+			hashtags := protected.Group("/hashtags")
+			{
+				hashtags.GET("/trending", h.GetTrendingHashtags)
+				hashtags.GET("/:tag/posts", h.GetHashtagPosts)
+			}
+
 			// Notification routes
 			notifications := protected.Group("/notifications")
 			{
@@ -107,6 +211,19 @@ func main() {
 			{
 				uploads.POST("/media", h.UploadMedia)
 			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.RequireRole(models.RoleAdmin, models.RoleModerator))
+			{
+				admin.GET("/users", h.ListUsers)
+				admin.POST("/users/:id/suspend", h.SuspendUser)
+				admin.DELETE("/users/:id/suspend", h.UnsuspendUser)
+				admin.POST("/users/:id/verify", h.VerifyUser)
+				admin.PUT("/users/:id/role", middleware.RequireRole(models.RoleAdmin), h.UpdateUserRole)
+				admin.DELETE("/posts/:id", h.TakeDownPost)
+				admin.GET("/audit", middleware.RequireRole(models.RoleAdmin), h.GetAuditLog)
+			}
 		}
 	}
 